@@ -1,9 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"strings"
+	"os/signal"
 	"time"
 
 	"github.com/x/smtp/smtp"
@@ -31,17 +32,28 @@ func main() {
 	}
 	defer f.Close()
 
+	// Cancel in-flight sends and waits on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	clientId := os.Getenv("CLIENT_ID")
 	clientSecret := os.Getenv("CLIENT_SECRET")
 
 	client := smtp.NewClient(clientId, clientSecret, "tokens")
-	if err := client.Init(); err != nil {
+	if err := client.InitContext(ctx); err != nil {
 		panic(err)
 	}
+	client.SetDefaultSender(smtp.Contact{Name: "Bachar Gmagour", Email: "bewerbung@bachargmagour.com"})
+	client.SetRateLimit(1, 5)
 
 	sheets := f.GetSheetList()
 
 	for si, sheet := range sheets {
+		if ctx.Err() != nil {
+			fmt.Println("🛑 Interrupted, stopping.")
+			return
+		}
+
 		fmt.Printf("📄 Processing sheet: %s\n", sheet)
 		rows, err := f.GetRows(sheet)
 		if err != nil {
@@ -54,44 +66,106 @@ func main() {
 			continue
 		}
 
+		// Collect the email column, skipping the first row (x), next 50
+		var emails []string
+		var filter smtp.RecipientFilter
+		emailRows := make(map[string]int)
+		for i := 1; i < len(rows) && i <= 50; i++ {
+			cell := ""
+			if len(rows[i]) > 0 {
+				cell = rows[i][0]
+			}
+			email, ok := filter.Accept(i+1, cell)
+			if !ok {
+				continue
+			}
+			emails = append(emails, email)
+			emailRows[email] = i + 1
+		}
+
+		// Never send to addresses on the account blacklist
+		var blacklisted map[string]bool
+		if len(emails) > 0 {
+			blacklisted, err = client.CheckBlacklistContext(ctx, emails)
+			if err != nil {
+				fmt.Printf("⚠️  Failed to check blacklist for sheet %s: %v\n", sheet, err)
+			}
+		}
+
+		// Scrub the column before sending
+		var validation map[string]smtp.EmailValidationResult
+		if len(emails) > 0 {
+			validation, err = client.ValidateEmailsContext(ctx, emails)
+			if err != nil {
+				fmt.Printf("⚠️  Failed to validate emails in sheet %s, sending unverified: %v\n", sheet, err)
+			}
+		}
+
+		// Check the sending limits once per sheet, pausing until the quota
+		// resets if the limit is reached. Fall back to a fixed cooldown
+		// between sheets if the limits can't be checked.
+		if throttle, wait, err := client.ShouldThrottleContext(ctx); err != nil {
+			fmt.Printf("⚠️  Failed to check sending limits: %v\n", err)
+			if si > 0 {
+				fmt.Printf("⏳ Waiting 70 minutes before next batch...\n")
+				waitFor(ctx, cooldown)
+			}
+		} else if throttle {
+			fmt.Printf("⏳ Sending limit reached, waiting %s...\n", wait.Round(time.Minute))
+			waitFor(ctx, wait)
+		}
+
 		sent := 0
-		for i := 1; i < len(rows) && i <= 50; i++ { // Skip first row (x), send next 50
-			if len(rows[i]) == 0 {
+		for _, email := range emails {
+			if ctx.Err() != nil {
+				break
+			}
+
+			if blacklisted[email] {
+				fmt.Printf("🚫 Skipping %s: blacklisted\n", email)
+				filter.Skip(emailRows[email], email, smtp.SkipBlacklisted)
 				continue
 			}
-			email := strings.TrimSpace(rows[i][0])
-			if email == "" {
+
+			if result, ok := validation[email]; ok && !result.Valid() {
+				fmt.Printf("🚫 Skipping %s: %s\n", email, result.Status)
+				filter.Skip(emailRows[email], email, smtp.SkipFailedCheck)
 				continue
 			}
 
-			emailData := map[string]interface{}{
-				"html":    templateStr,
-				"text":    "",
-				"subject": "Bewerbung um einen Ausbildungsplatz als Bauzeichner",
-				"from":    map[string]string{"name": "Bachar Gmagour", "email": "bewerbung@bachargmagour.com"},
-				"to":      []map[string]string{{"email": email}},
+			message := smtp.SMTPEmail{
+				HTML:    templateStr,
+				Subject: "Bewerbung um einen Ausbildungsplatz als Bauzeichner",
+				To:      []smtp.Contact{{Email: email}},
 			}
 
-			err := client.SMTPSendMail(emailData)
+			result, err := client.SMTPSendContext(ctx, message)
 			if err != nil {
 				fmt.Printf("❌ Failed to send email to %s: %v\n", email, err)
 			} else {
-				fmt.Printf("✅ Email sent to %s (sheet: %s, row: %d)\n", email, sheet, i+1)
+				fmt.Printf("✅ Email sent to %s (sheet: %s, row: %d, id: %s)\n", email, sheet, emailRows[email], result.ID)
 				sent++
 			}
 		}
 
 		fmt.Printf("✅ Finished sheet %s: %d emails sent\n", sheet, sent)
-
-		// Wait before next batch
-		if si < len(sheets)-1 {
-			fmt.Printf("⏳ Waiting 70 minutes before next batch...\n")
-			for remaining := cooldown; remaining > 0; remaining -= time.Minute {
-				fmt.Printf("🕒 %d minutes remaining...\n", int(remaining.Minutes()))
-				time.Sleep(time.Minute)
-			}
+		for _, skipped := range filter.Skipped {
+			fmt.Printf("⏭️  Skipped row %d (%q): %s\n", skipped.Row, skipped.Value, skipped.Reason)
 		}
 	}
 
 	fmt.Println("🎉 All sheets processed!")
 }
+
+// waitFor sleeps for d, printing the remaining time every minute, and
+// returns early if ctx is cancelled
+func waitFor(ctx context.Context, d time.Duration) {
+	for remaining := d; remaining > 0; remaining -= time.Minute {
+		fmt.Printf("🕒 %d minutes remaining...\n", int(remaining.Minutes()))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(min(remaining, time.Minute)):
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package smtp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// AutomationStatus represents the state of an Automation360 flow
+type AutomationStatus int
+
+// Automation statuses
+const (
+	AutomationInactive AutomationStatus = 0
+	AutomationActive   AutomationStatus = 1
+	AutomationPaused   AutomationStatus = 2
+)
+
+// String returns a readable name for the status
+func (s AutomationStatus) String() string {
+	switch s {
+	case AutomationInactive:
+		return "inactive"
+	case AutomationActive:
+		return "active"
+	case AutomationPaused:
+		return "paused"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Automation represents an Automation360 flow
+type Automation struct {
+	ID      int              `json:"id"`
+	Name    string           `json:"name"`
+	Status  AutomationStatus `json:"status"`
+	Created string           `json:"created,omitempty"`
+	Changed string           `json:"changed,omitempty"`
+}
+
+// AutomationStats represents the delivery statistics of an Automation360 flow
+type AutomationStats struct {
+	ID        int `json:"id"`
+	Sent      int `json:"sent"`
+	Delivered int `json:"delivered"`
+	Opened    int `json:"opened"`
+	Clicked   int `json:"clicked"`
+	Errors    int `json:"errors"`
+	Converted int `json:"converted"`
+}
+
+// ListAutomations retrieves the list of Automation360 flows
+func (c *Client) ListAutomations() ([]Automation, error) {
+	return c.ListAutomationsContext(context.Background())
+}
+
+// ListAutomationsContext is like ListAutomations but uses ctx for cancellation and deadlines
+func (c *Client) ListAutomationsContext(ctx context.Context) ([]Automation, error) {
+	resp, err := c.sendRequest(ctx, "a360/autoresponders/list", "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data []Automation `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse automations: %w", err)
+	}
+
+	return result.Data, nil
+}
+
+// GetAutomationStats retrieves statistics for an Automation360 flow
+func (c *Client) GetAutomationStats(id int) (*AutomationStats, error) {
+	return c.GetAutomationStatsContext(context.Background(), id)
+}
+
+// GetAutomationStatsContext is like GetAutomationStats but uses ctx for cancellation and deadlines
+func (c *Client) GetAutomationStatsContext(ctx context.Context, id int) (*AutomationStats, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("empty automation id")
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("a360/stats/main/%d", id), "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data AutomationStats `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse automation stats: %w", err)
+	}
+
+	return &result.Data, nil
+}
+
+// EventsURL is the default base URL of the Automation360 events service
+const EventsURL = "https://events.sendpulse.com"
+
+// Event represents an Automation360 event for a subscriber. Email or Phone
+// identifies the subscriber; Variables are passed to the automation flow and
+// may not use the reserved names "email" and "phone".
+type Event struct {
+	Email     string
+	Phone     string
+	Variables map[string]interface{}
+}
+
+// TriggerEvent sends an event to the Automation360 flows listening for eventName
+func (c *Client) TriggerEvent(eventName string, event Event) error {
+	return c.TriggerEventContext(context.Background(), eventName, event)
+}
+
+// TriggerEventContext is like TriggerEvent but uses ctx for cancellation and deadlines
+func (c *Client) TriggerEventContext(ctx context.Context, eventName string, event Event) error {
+	if c.paused.Load() {
+		return ErrClientPaused
+	}
+
+	if eventName == "" {
+		return fmt.Errorf("empty event name")
+	}
+	if event.Email == "" && event.Phone == "" {
+		return fmt.Errorf("event requires an email or phone")
+	}
+
+	data := make(map[string]interface{}, len(event.Variables)+2)
+	for key, value := range event.Variables {
+		if key == "email" || key == "phone" {
+			return fmt.Errorf("event variable %q is reserved for the subscriber identity", key)
+		}
+		data[key] = value
+	}
+	if event.Email != "" {
+		data["email"] = event.Email
+	}
+	if event.Phone != "" {
+		data["phone"] = event.Phone
+	}
+
+	_, _, err := c.doRequestTo(ctx, c.eventsURL, "events/name/"+url.PathEscape(eventName), "POST", data, true)
+	return err
+}
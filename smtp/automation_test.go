@@ -0,0 +1,109 @@
+package smtp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestListAutomations(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/a360/autoresponders/list" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"data":[{"id":1,"name":"Welcome","status":1},{"id":2,"name":"Winback","status":2}]}`))
+	}))
+
+	automations, err := c.ListAutomations()
+	if err != nil {
+		t.Fatalf("ListAutomations: %v", err)
+	}
+	if len(automations) != 2 {
+		t.Fatalf("got %d automations, want 2", len(automations))
+	}
+	if automations[0].Status != AutomationActive || automations[1].Status != AutomationPaused {
+		t.Errorf("statuses = %v, %v", automations[0].Status, automations[1].Status)
+	}
+	if got := automations[1].Status.String(); got != "paused" {
+		t.Errorf("String() = %q, want paused", got)
+	}
+}
+
+func TestGetAutomationStats(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/a360/stats/main/7" {
+			t.Errorf("path = %q, want /a360/stats/main/7", r.URL.Path)
+		}
+		w.Write([]byte(`{"data":{"id":7,"sent":100,"delivered":95,"opened":40,"clicked":12,"errors":5,"converted":3}}`))
+	}))
+
+	stats, err := c.GetAutomationStats(7)
+	if err != nil {
+		t.Fatalf("GetAutomationStats: %v", err)
+	}
+	want := AutomationStats{ID: 7, Sent: 100, Delivered: 95, Opened: 40, Clicked: 12, Errors: 5, Converted: 3}
+	if *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+
+	if _, err := c.GetAutomationStats(0); err == nil {
+		t.Error("GetAutomationStats(0) succeeded, want error")
+	}
+}
+
+func TestAutomationStatusString(t *testing.T) {
+	if got := AutomationStatus(9).String(); got != "unknown(9)" {
+		t.Errorf("String() = %q, want unknown(9)", got)
+	}
+}
+
+func TestTriggerEventUsesEventsURL(t *testing.T) {
+	events := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.EscapedPath() != "/events/name/order%20placed" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer token")
+		}
+
+		var data map[string]interface{}
+		readJSON(t, r, &data)
+		if data["email"] != "a@example.com" || data["plan"] != "pro" {
+			t.Errorf("unexpected event payload %v", data)
+		}
+		writeJSON(t, w, map[string]bool{"result": true})
+	}))
+	defer events.Close()
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("event sent to the API host: %s %s", r.Method, r.URL.Path)
+	}))
+	WithEventsBaseURL(events.URL)(c)
+
+	event := Event{Email: "a@example.com", Variables: map[string]interface{}{"plan": "pro"}}
+	if err := c.TriggerEvent("order placed", event); err != nil {
+		t.Fatalf("TriggerEvent: %v", err)
+	}
+
+	// Variables can't override or stand in for the subscriber identity
+	for _, event := range []Event{
+		{Email: "a@example.com", Variables: map[string]interface{}{"email": "b@example.com"}},
+		{Email: "a@example.com", Variables: map[string]interface{}{"phone": "+491511234567"}},
+	} {
+		if err := c.TriggerEvent("order placed", event); err == nil || !strings.Contains(err.Error(), "reserved") {
+			t.Errorf("TriggerEvent(%v) = %v, want a reserved variable error", event.Variables, err)
+		}
+	}
+}
+
+func TestTriggerEventPaused(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("paused client sent %s %s", r.Method, r.URL.Path)
+	}))
+	c.Pause()
+
+	if err := c.TriggerEvent("signup", Event{Email: "a@example.com"}); err != ErrClientPaused {
+		t.Fatalf("TriggerEvent error = %v, want ErrClientPaused", err)
+	}
+}
@@ -0,0 +1,302 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	// tokenRefreshMargin is how close to expiry SendBatch refreshes the token
+	tokenRefreshMargin = 5 * time.Minute
+
+	// batchRetryDelay is the pause before retrying a failed send in a batch
+	batchRetryDelay = 2 * time.Second
+)
+
+// SendWindow restricts sending to a daily time-of-day window. Start and End
+// are offsets from midnight in Location; a window with End before Start spans
+// midnight.
+type SendWindow struct {
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// contains reports whether t falls inside the window
+func (w SendWindow) contains(t time.Time) bool {
+	offset := w.offset(t)
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// next returns t if it falls inside the window, otherwise the time the window
+// next opens
+func (w SendWindow) next(t time.Time) time.Time {
+	if w.contains(t) {
+		return t
+	}
+
+	local := t.In(w.location())
+	open := clockTime(local, 0, w.Start)
+	if open.Before(t) {
+		open = clockTime(local, 1, w.Start)
+	}
+	return open
+}
+
+// offset returns the wall-clock time of day at t in the window's location.
+// It reads the clock rather than measuring from midnight, so days with a DST
+// change don't shift the window by an hour.
+func (w SendWindow) offset(t time.Time) time.Duration {
+	local := t.In(w.location())
+	return time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second + time.Duration(local.Nanosecond())
+}
+
+// clockTime returns the time at wall-clock time of day offset, days after the
+// date of local, in local's location
+func clockTime(local time.Time, days int, offset time.Duration) time.Time {
+	return time.Date(local.Year(), local.Month(), local.Day()+days,
+		int(offset/time.Hour), int(offset%time.Hour/time.Minute), int(offset%time.Minute/time.Second),
+		int(offset%time.Second), local.Location())
+}
+
+func (w SendWindow) location() *time.Location {
+	if w.Location == nil {
+		return time.UTC
+	}
+	return w.Location
+}
+
+// BatchResult represents the outcome of sending one email in a batch
+type BatchResult struct {
+	Recipient string
+	ID        string
+	Attempts  int
+	Err       error
+}
+
+// BatchSummary summarizes a batch run
+type BatchSummary struct {
+	Sent    int
+	Failed  int
+	Retries int
+	Results []BatchResult
+	Skipped []SkippedRow
+}
+
+// SkipReason explains why a recipient was not sent to
+type SkipReason string
+
+// Reasons a recipient is skipped
+const (
+	SkipEmpty       SkipReason = "empty cell"
+	SkipMalformed   SkipReason = "malformed address"
+	SkipDuplicate   SkipReason = "duplicate"
+	SkipFailedCheck SkipReason = "failed validation"
+	SkipBlacklisted SkipReason = "blacklisted"
+)
+
+// SkippedRow records a recipient that was not sent to and why. Row is the
+// spreadsheet row for RecipientFilter, or the index of the email in the batch
+// for SendBatch.
+type SkippedRow struct {
+	Row    int
+	Value  string
+	Reason SkipReason
+}
+
+// RecipientFilter classifies recipient rows, accepting each well-formed
+// address once and recording the rows it skips. The zero value is ready to use.
+type RecipientFilter struct {
+	Skipped []SkippedRow
+	seen    map[string]bool
+}
+
+// Accept reports whether value from row should be sent to, returning the
+// trimmed address. Rejected rows are added to Skipped.
+func (f *RecipientFilter) Accept(row int, value string) (string, bool) {
+	email := strings.TrimSpace(value)
+	reason := f.classify(email)
+	if reason != "" {
+		f.Skip(row, value, reason)
+		return "", false
+	}
+
+	if f.seen == nil {
+		f.seen = make(map[string]bool)
+	}
+	f.seen[NormalizeEmail(email, NormalizeOptions{})] = true
+	return email, true
+}
+
+// Skip records row as skipped for reason
+func (f *RecipientFilter) Skip(row int, value string, reason SkipReason) {
+	f.Skipped = append(f.Skipped, SkippedRow{Row: row, Value: value, Reason: reason})
+}
+
+// classify returns why email should be skipped, or an empty reason
+func (f *RecipientFilter) classify(email string) SkipReason {
+	if email == "" {
+		return SkipEmpty
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return SkipMalformed
+	}
+
+	if f.seen[NormalizeEmail(email, NormalizeOptions{})] {
+		return SkipDuplicate
+	}
+	return ""
+}
+
+// BatchRunner sends batches of SMTP emails through a Client
+type BatchRunner struct {
+	Client *Client
+
+	// Window restricts sends to a daily time window. Nil sends at any time.
+	Window *SendWindow
+
+	// MaxRetries is the number of times a failed send is retried
+	MaxRetries int
+
+	// RetryBudget caps the total retries across a whole batch. Once it is
+	// spent, failures are recorded without retrying. Zero means unlimited.
+	RetryBudget int
+
+	// SkipInvalid skips emails whose first recipient is empty, malformed or
+	// already sent to in this batch, recording them in the summary
+	SkipInvalid bool
+
+	// now and sleep default to time.Now and sleepContext when nil, so a
+	// runner built as a literal works too
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewBatchRunner creates a new batch runner sending through client
+func NewBatchRunner(client *Client) *BatchRunner {
+	return &BatchRunner{
+		Client: client,
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+}
+
+// SendBatch sends each email in turn, waiting for the send window to open
+// when one is configured. It stops early if ctx is cancelled.
+func (r *BatchRunner) SendBatch(ctx context.Context, emails []SMTPEmail) (*BatchSummary, error) {
+	if len(emails) == 0 {
+		return nil, fmt.Errorf("empty email batch")
+	}
+
+	summary := &BatchSummary{}
+	var filter RecipientFilter
+	for i, email := range emails {
+		if r.SkipInvalid {
+			recipient := ""
+			if len(email.To) > 0 {
+				recipient = email.To[0].Email
+			}
+			if _, ok := filter.Accept(i, recipient); !ok {
+				summary.Skipped = filter.Skipped
+				continue
+			}
+		}
+
+		if err := r.waitForWindow(ctx); err != nil {
+			return summary, err
+		}
+
+		// Refresh once ahead of expiry instead of failing a send with 401
+		if err := r.Client.refreshTokenIfExpiring(ctx, tokenRefreshMargin); err != nil {
+			return summary, fmt.Errorf("%w: %w", ErrTokenRefreshFailed, err)
+		}
+
+		result := BatchResult{}
+		if len(email.To) > 0 {
+			result.Recipient = email.To[0].Email
+		}
+
+		sent, err := r.send(ctx, email, summary, &result)
+		if err != nil {
+			result.Err = err
+			summary.Failed++
+		} else {
+			result.ID = sent.ID
+			summary.Sent++
+		}
+		summary.Results = append(summary.Results, result)
+	}
+
+	return summary, nil
+}
+
+// send sends one email, retrying failures while both the per-email retries
+// and the batch's retry budget allow
+func (r *BatchRunner) send(ctx context.Context, email SMTPEmail, summary *BatchSummary, result *BatchResult) (*SMTPSendResult, error) {
+	for {
+		result.Attempts++
+		sent, err := r.Client.SMTPSendContext(ctx, email)
+		if err == nil || ctx.Err() != nil || errors.Is(err, ErrClientPaused) {
+			return sent, err
+		}
+
+		if result.Attempts > r.MaxRetries || (r.RetryBudget > 0 && summary.Retries >= r.RetryBudget) {
+			return nil, err
+		}
+		summary.Retries++
+
+		if err := r.sleepFor(ctx, batchRetryDelay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitForWindow blocks until the send window is open or ctx is cancelled
+func (r *BatchRunner) waitForWindow(ctx context.Context) error {
+	if r.Window == nil {
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+
+	open := r.Window.next(now)
+	if !open.After(now) {
+		return ctx.Err()
+	}
+
+	return r.sleepFor(ctx, open.Sub(now))
+}
+
+// sleepFor sleeps for d or until ctx is cancelled
+func (r *BatchRunner) sleepFor(ctx context.Context, d time.Duration) error {
+	if r.sleep == nil {
+		return sleepContext(ctx, d)
+	}
+	return r.sleep(ctx, d)
+}
+
+// sleepContext sleeps for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
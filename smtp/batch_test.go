@@ -0,0 +1,317 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// smtpSendHandler answers SMTP sends with the given id and counts them
+func smtpSendHandler(t *testing.T, sends *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/smtp/emails" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			return
+		}
+		sends.Add(1)
+		writeJSON(t, w, map[string]interface{}{"result": true, "id": "msg"})
+	})
+}
+
+func testEmail(to string) SMTPEmail {
+	return SMTPEmail{
+		From:    Contact{Email: "from@example.com"},
+		To:      []Contact{{Email: to}},
+		Subject: "Hello",
+		HTML:    "<p>Hello</p>",
+	}
+}
+
+func TestSendWindowSpansMidnight(t *testing.T) {
+	w := SendWindow{Start: 22 * time.Hour, End: 6 * time.Hour}
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		at   time.Duration
+		open bool
+	}{
+		{23 * time.Hour, true},
+		{2 * time.Hour, true},
+		{6 * time.Hour, false},
+		{12 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		if got := w.contains(day.Add(tt.at)); got != tt.open {
+			t.Errorf("contains(%v) = %v, want %v", tt.at, got, tt.open)
+		}
+	}
+
+	if got, want := w.next(day.Add(12*time.Hour)), day.Add(22*time.Hour); !got.Equal(want) {
+		t.Errorf("next = %v, want %v", got, want)
+	}
+}
+
+func TestSendWindowAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := SendWindow{Start: 9 * time.Hour, End: 17 * time.Hour, Location: berlin}
+
+	// Clocks go from 02:00 to 03:00 on 2024-03-31 and from 03:00 back to
+	// 02:00 on 2024-10-27, so those days are 23 and 25 hours long
+	for _, day := range []int{31, 27} {
+		month := time.March
+		if day == 27 {
+			month = time.October
+		}
+		at := func(hour, min int) time.Time {
+			return time.Date(2024, month, day, hour, min, 0, 0, berlin)
+		}
+
+		tests := []struct {
+			at   time.Time
+			open bool
+		}{
+			{at(8, 30), false},
+			{at(9, 0), true},
+			{at(16, 30), true},
+			{at(17, 0), false},
+		}
+		for _, tt := range tests {
+			if got := w.contains(tt.at); got != tt.open {
+				t.Errorf("contains(%v) = %v, want %v", tt.at, got, tt.open)
+			}
+		}
+
+		if got, want := w.next(at(6, 0)), at(9, 0); !got.Equal(want) {
+			t.Errorf("next(%v) = %v, want %v", at(6, 0), got, want)
+		}
+	}
+
+	// Opening the next morning after a DST change still lands on 09:00
+	evening := time.Date(2024, time.March, 30, 20, 0, 0, 0, berlin)
+	if got, want := w.next(evening), time.Date(2024, time.March, 31, 9, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("next(%v) = %v, want %v", evening, got, want)
+	}
+}
+
+func TestSendBatchWaitsForWindow(t *testing.T) {
+	var sends atomic.Int32
+	c := newTestClient(t, smtpSendHandler(t, &sends))
+
+	// The fake clock starts at 20:00 and only moves when the runner sleeps
+	now := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	r := NewBatchRunner(c)
+	r.Window = &SendWindow{Start: 9 * time.Hour, End: 17 * time.Hour}
+	r.now = func() time.Time { return now }
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		if sends.Load() != 0 {
+			t.Errorf("sent before the window opened")
+		}
+		slept = append(slept, d)
+		now = now.Add(d)
+		return nil
+	}
+
+	summary, err := r.SendBatch(context.Background(), []SMTPEmail{testEmail("a@example.com"), testEmail("b@example.com")})
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+
+	if len(slept) != 1 || slept[0] != 13*time.Hour {
+		t.Errorf("slept %v, want a single 13h wait", slept)
+	}
+	if summary.Sent != 2 || sends.Load() != 2 {
+		t.Errorf("sent %d (%d requests), want 2", summary.Sent, sends.Load())
+	}
+}
+
+func TestSendBatchCancelledOutsideWindow(t *testing.T) {
+	var sends atomic.Int32
+	c := newTestClient(t, smtpSendHandler(t, &sends))
+
+	// Open the window an hour from now so the batch has to wait. The runner
+	// is built as a literal and falls back to the real clock.
+	offset := time.Since(time.Now().UTC().Truncate(24 * time.Hour))
+	start := (offset + time.Hour) % (24 * time.Hour)
+	r := &BatchRunner{
+		Client: c,
+		Window: &SendWindow{Start: start, End: (start + time.Hour) % (24 * time.Hour)},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	summary, err := r.SendBatch(ctx, []SMTPEmail{testEmail("a@example.com")})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SendBatch error = %v, want context.DeadlineExceeded", err)
+	}
+	if summary.Sent != 0 || sends.Load() != 0 {
+		t.Errorf("sent %d emails outside the window", sends.Load())
+	}
+}
+
+func TestBatchRunnerLiteralRetries(t *testing.T) {
+	var sends atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sends.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	// The retry delay outlasts the deadline, so the retry sleep is cut short
+	r := &BatchRunner{Client: c, MaxRetries: 1}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	summary, err := r.SendBatch(ctx, []SMTPEmail{testEmail("a@example.com")})
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	if summary.Failed != 1 || !errors.Is(summary.Results[0].Err, context.DeadlineExceeded) {
+		t.Errorf("summary = %+v, want one failure with context.DeadlineExceeded", summary)
+	}
+	if sends.Load() != 1 {
+		t.Errorf("sent %d requests, want 1", sends.Load())
+	}
+}
+
+func TestSendBatchRefreshesExpiringToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+	}{
+		// Only SendBatch's wider margin sees this token as expiring
+		{"within batch margin", 3 * time.Minute},
+		// Both SendBatch and the request itself see this one as expiring
+		{"within request skew", 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches, sends atomic.Int32
+			c := newTestClient(t, authHandler(t, &fetches, smtpSendHandler(t, &sends)))
+			c.Token = "stale"
+			c.tokenExpiresAt = time.Now().Add(tt.expiresIn)
+
+			emails := []SMTPEmail{testEmail("a@example.com"), testEmail("b@example.com"), testEmail("c@example.com")}
+			summary, err := NewBatchRunner(c).SendBatch(context.Background(), emails)
+			if err != nil {
+				t.Fatalf("SendBatch: %v", err)
+			}
+
+			if n := fetches.Load(); n != 1 {
+				t.Errorf("token fetched %d times, want 1", n)
+			}
+			if summary.Sent != 3 || sends.Load() != 3 {
+				t.Errorf("sent %d (%d requests), want 3", summary.Sent, sends.Load())
+			}
+		})
+	}
+}
+
+func TestSendBatchRefreshFailure(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth/access_token" {
+			t.Errorf("sent %s %s with an expired token", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	c.tokenExpiresAt = time.Now().Add(time.Minute)
+
+	_, err := NewBatchRunner(c).SendBatch(context.Background(), []SMTPEmail{testEmail("a@example.com")})
+	if !errors.Is(err, ErrTokenRefreshFailed) {
+		t.Fatalf("SendBatch error = %v, want ErrTokenRefreshFailed", err)
+	}
+}
+
+func TestSendBatchRetryBudget(t *testing.T) {
+	var sends atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sends.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	r := NewBatchRunner(c)
+	r.MaxRetries = 3
+	r.RetryBudget = 4
+	r.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+
+	emails := []SMTPEmail{testEmail("a@example.com"), testEmail("b@example.com"), testEmail("c@example.com")}
+	summary, err := r.SendBatch(context.Background(), emails)
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+
+	// The first email uses 3 retries and the second the last one; the third
+	// fails without retrying
+	attempts := []int{4, 2, 1}
+	for i, result := range summary.Results {
+		if result.Attempts != attempts[i] || result.Err == nil {
+			t.Errorf("email %d: %d attempts, err %v; want %d attempts and an error", i, result.Attempts, result.Err, attempts[i])
+		}
+	}
+	if summary.Retries != 4 || summary.Failed != 3 || sends.Load() != 7 {
+		t.Errorf("retries %d, failed %d, requests %d; want 4, 3, 7", summary.Retries, summary.Failed, sends.Load())
+	}
+}
+
+func TestRecipientFilter(t *testing.T) {
+	var f RecipientFilter
+	rows := []string{"a@example.com", "", "   ", "not-an-email", "Ann <ann@example.com>", " A@Example.com ", "b@example.com"}
+
+	var accepted []string
+	for i, value := range rows {
+		if email, ok := f.Accept(i+2, value); ok {
+			accepted = append(accepted, email)
+		}
+	}
+
+	if want := []string{"a@example.com", "b@example.com"}; !reflect.DeepEqual(accepted, want) {
+		t.Errorf("accepted %v, want %v", accepted, want)
+	}
+
+	want := []SkippedRow{
+		{Row: 3, Value: "", Reason: SkipEmpty},
+		{Row: 4, Value: "   ", Reason: SkipEmpty},
+		{Row: 5, Value: "not-an-email", Reason: SkipMalformed},
+		{Row: 6, Value: "Ann <ann@example.com>", Reason: SkipMalformed},
+		{Row: 7, Value: " A@Example.com ", Reason: SkipDuplicate},
+	}
+	if !reflect.DeepEqual(f.Skipped, want) {
+		t.Errorf("skipped %+v, want %+v", f.Skipped, want)
+	}
+}
+
+func TestSendBatchSkipInvalid(t *testing.T) {
+	var sends atomic.Int32
+	c := newTestClient(t, smtpSendHandler(t, &sends))
+
+	r := NewBatchRunner(c)
+	r.SkipInvalid = true
+
+	noRecipient := testEmail("")
+	noRecipient.To = nil
+	emails := []SMTPEmail{testEmail("a@example.com"), noRecipient, testEmail("bad"), testEmail("a@example.com"), testEmail("b@example.com")}
+	summary, err := r.SendBatch(context.Background(), emails)
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+
+	want := []SkippedRow{
+		{Row: 1, Value: "", Reason: SkipEmpty},
+		{Row: 2, Value: "bad", Reason: SkipMalformed},
+		{Row: 3, Value: "a@example.com", Reason: SkipDuplicate},
+	}
+	if !reflect.DeepEqual(summary.Skipped, want) {
+		t.Errorf("skipped %+v, want %+v", summary.Skipped, want)
+	}
+	if summary.Sent != 2 || sends.Load() != 2 {
+		t.Errorf("sent %d (%d requests), want 2", summary.Sent, sends.Load())
+	}
+}
@@ -0,0 +1,131 @@
+package smtp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GetBlacklist retrieves the email addresses on the account blacklist
+func (c *Client) GetBlacklist() ([]string, error) {
+	return c.GetBlacklistContext(context.Background())
+}
+
+// GetBlacklistContext is like GetBlacklist but uses ctx for cancellation and deadlines
+func (c *Client) GetBlacklistContext(ctx context.Context) ([]string, error) {
+	resp, err := c.sendRequest(ctx, "blacklist", "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var emails []string
+	if err := json.Unmarshal(resp, &emails); err != nil {
+		return nil, fmt.Errorf("failed to parse blacklist: %w", err)
+	}
+
+	return emails, nil
+}
+
+// AddToBlacklist adds email addresses to the account blacklist, which stops
+// any email from being sent to them
+func (c *Client) AddToBlacklist(emails []string, comment string) error {
+	return c.AddToBlacklistContext(context.Background(), emails, comment)
+}
+
+// AddToBlacklistContext is like AddToBlacklist but uses ctx for cancellation and deadlines
+func (c *Client) AddToBlacklistContext(ctx context.Context, emails []string, comment string) error {
+	if len(emails) == 0 {
+		return fmt.Errorf("empty email list")
+	}
+
+	data := map[string]string{"emails": encodeBlacklistEmails(emails)}
+	if comment != "" {
+		data["comment"] = comment
+	}
+
+	_, err := c.sendRequest(ctx, "blacklist", "POST", data, true)
+	return err
+}
+
+// RemoveFromBlacklist removes email addresses from the account blacklist
+func (c *Client) RemoveFromBlacklist(emails []string) error {
+	return c.RemoveFromBlacklistContext(context.Background(), emails)
+}
+
+// RemoveFromBlacklistContext is like RemoveFromBlacklist but uses ctx for cancellation and deadlines
+func (c *Client) RemoveFromBlacklistContext(ctx context.Context, emails []string) error {
+	if len(emails) == 0 {
+		return fmt.Errorf("empty email list")
+	}
+
+	data := map[string]string{"emails": encodeBlacklistEmails(emails)}
+	_, err := c.sendRequest(ctx, "blacklist", "DELETE", data, true)
+	return err
+}
+
+// encodeBlacklistEmails encodes emails the way the blacklist endpoints expect
+// them: comma-joined and base64-encoded
+func encodeBlacklistEmails(emails []string) string {
+	trimmed := make([]string, len(emails))
+	for i, email := range emails {
+		trimmed[i] = strings.TrimSpace(email)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(strings.Join(trimmed, ",")))
+}
+
+// CheckBlacklist reports which of emails are on the account blacklist with a
+// single request. Every address is present in the result.
+func (c *Client) CheckBlacklist(emails []string) (map[string]bool, error) {
+	return c.CheckBlacklistContext(context.Background(), emails)
+}
+
+// CheckBlacklistContext is like CheckBlacklist but uses ctx for cancellation and deadlines
+func (c *Client) CheckBlacklistContext(ctx context.Context, emails []string) (map[string]bool, error) {
+	if len(emails) == 0 {
+		return nil, fmt.Errorf("empty email list")
+	}
+
+	params := map[string]string{"emails": encodeBlacklistEmails(emails)}
+	resp, err := c.sendRequest(ctx, "blacklist", "GET", params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var listed []string
+	if err := json.Unmarshal(resp, &listed); err != nil {
+		return nil, fmt.Errorf("failed to parse blacklist: %w", err)
+	}
+
+	found := make(map[string]bool, len(listed))
+	for _, email := range listed {
+		found[NormalizeEmail(email, NormalizeOptions{})] = true
+	}
+
+	result := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		result[email] = found[NormalizeEmail(email, NormalizeOptions{})]
+	}
+
+	return result, nil
+}
+
+// IsBlacklisted reports whether email is on the account blacklist
+func (c *Client) IsBlacklisted(email string) (bool, error) {
+	return c.IsBlacklistedContext(context.Background(), email)
+}
+
+// IsBlacklistedContext is like IsBlacklisted but uses ctx for cancellation and deadlines
+func (c *Client) IsBlacklistedContext(ctx context.Context, email string) (bool, error) {
+	if email == "" {
+		return false, fmt.Errorf("empty email")
+	}
+
+	result, err := c.CheckBlacklistContext(ctx, []string{email})
+	if err != nil {
+		return false, err
+	}
+
+	return result[email], nil
+}
@@ -0,0 +1,184 @@
+package smtp
+
+import (
+	"encoding/base64"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// decodeBlacklistEmails decodes a comma-joined base64 email list
+func decodeBlacklistEmails(t *testing.T, encoded string) []string {
+	t.Helper()
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("emails %q are not base64: %v", encoded, err)
+	}
+	return strings.Split(string(decoded), ",")
+}
+
+func TestBlacklistEncoding(t *testing.T) {
+	type request struct {
+		method  string
+		emails  []string
+		comment string
+	}
+	var requests []request
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/blacklist" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Method == "GET" {
+			w.Write([]byte(`["spam@example.com"]`))
+			return
+		}
+
+		var data map[string]string
+		readJSON(t, r, &data)
+		requests = append(requests, request{r.Method, decodeBlacklistEmails(t, data["emails"]), data["comment"]})
+		writeJSON(t, w, map[string]bool{"result": true})
+	}))
+
+	if err := c.AddToBlacklist([]string{" a@example.com", "b@example.com "}, "complaint"); err != nil {
+		t.Fatalf("AddToBlacklist: %v", err)
+	}
+	if err := c.RemoveFromBlacklist([]string{"a@example.com"}); err != nil {
+		t.Fatalf("RemoveFromBlacklist: %v", err)
+	}
+
+	want := []request{
+		{"POST", []string{"a@example.com", "b@example.com"}, "complaint"},
+		{"DELETE", []string{"a@example.com"}, ""},
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %+v, want %+v", requests, want)
+	}
+
+	emails, err := c.GetBlacklist()
+	if err != nil {
+		t.Fatalf("GetBlacklist: %v", err)
+	}
+	if want := []string{"spam@example.com"}; !reflect.DeepEqual(emails, want) {
+		t.Errorf("blacklist = %v, want %v", emails, want)
+	}
+
+	if err := c.AddToBlacklist(nil, ""); err == nil {
+		t.Error("AddToBlacklist with no emails succeeded, want error")
+	}
+}
+
+func TestCheckBlacklist(t *testing.T) {
+	var requests int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != "GET" || r.URL.Path != "/blacklist" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		emails := decodeBlacklistEmails(t, r.URL.Query().Get("emails"))
+		if want := []string{"Spam@Example.com", "ok@example.com"}; requests == 1 && !reflect.DeepEqual(emails, want) {
+			t.Errorf("checked %v, want %v", emails, want)
+		}
+		w.Write([]byte(`["spam@example.com"]`))
+	}))
+
+	result, err := c.CheckBlacklist([]string{"Spam@Example.com", "ok@example.com"})
+	if err != nil {
+		t.Fatalf("CheckBlacklist: %v", err)
+	}
+	if want := map[string]bool{"Spam@Example.com": true, "ok@example.com": false}; !reflect.DeepEqual(result, want) {
+		t.Errorf("result = %v, want %v", result, want)
+	}
+
+	listed, err := c.IsBlacklisted("spam@example.com")
+	if err != nil || !listed {
+		t.Errorf("IsBlacklisted = %v, %v; want true", listed, err)
+	}
+	if requests != 2 {
+		t.Errorf("sent %d requests, want one per call", requests)
+	}
+
+	if _, err := c.IsBlacklisted(""); err == nil {
+		t.Error("IsBlacklisted of an empty email succeeded, want error")
+	}
+}
+
+// blacklistServer keeps a blacklist in memory behind the blacklist endpoints
+func blacklistServer(t *testing.T) http.Handler {
+	listed := make(map[string]bool)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/blacklist" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			return
+		}
+
+		switch r.Method {
+		case "GET":
+			emails := []string{}
+			for email := range listed {
+				emails = append(emails, email)
+			}
+			sort.Strings(emails)
+			writeJSON(t, w, emails)
+			return
+		case "POST", "DELETE":
+			var data map[string]string
+			readJSON(t, r, &data)
+			for _, email := range decodeBlacklistEmails(t, data["emails"]) {
+				if r.Method == "POST" {
+					listed[email] = true
+				} else {
+					delete(listed, email)
+				}
+			}
+		}
+		writeJSON(t, w, map[string]bool{"result": true})
+	})
+}
+
+func TestBlacklistManagement(t *testing.T) {
+	c := newTestClient(t, blacklistServer(t))
+
+	if err := c.AddToBlacklist([]string{"a@example.com", "b@example.com"}, ""); err != nil {
+		t.Fatalf("AddToBlacklist: %v", err)
+	}
+	if err := c.RemoveFromBlacklist([]string{"a@example.com"}); err != nil {
+		t.Fatalf("RemoveFromBlacklist: %v", err)
+	}
+
+	emails, err := c.GetBlacklist()
+	if err != nil {
+		t.Fatalf("GetBlacklist: %v", err)
+	}
+	if want := []string{"b@example.com"}; !reflect.DeepEqual(emails, want) {
+		t.Fatalf("blacklist = %v, want %v", emails, want)
+	}
+
+	// Recipients on the blacklist are skipped the way the batch sender does
+	blacklisted := make(map[string]bool)
+	for _, email := range emails {
+		blacklisted[NormalizeEmail(email, NormalizeOptions{})] = true
+	}
+	var filter RecipientFilter
+	var accepted []string
+	for i, value := range []string{"a@example.com", "B@example.com"} {
+		email, ok := filter.Accept(i+2, value)
+		if !ok {
+			continue
+		}
+		if blacklisted[NormalizeEmail(email, NormalizeOptions{})] {
+			filter.Skip(i+2, value, SkipBlacklisted)
+			continue
+		}
+		accepted = append(accepted, email)
+	}
+
+	if !reflect.DeepEqual(accepted, []string{"a@example.com"}) {
+		t.Errorf("accepted %v, want only a@example.com", accepted)
+	}
+	if want := []SkippedRow{{Row: 3, Value: "B@example.com", Reason: SkipBlacklisted}}; !reflect.DeepEqual(filter.Skipped, want) {
+		t.Errorf("skipped %+v, want %+v", filter.Skipped, want)
+	}
+}
@@ -0,0 +1,58 @@
+package smtp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// DNSRecord is a DNS record a sending domain needs for authentication, with
+// the value the API expects and the value it found
+type DNSRecord struct {
+	// Kind is the authentication the record provides: spf, dkim or dmarc
+	Kind     string   `json:"kind"`
+	Type     string   `json:"type"`
+	Name     string   `json:"name"`
+	Value    string   `json:"value"`
+	Actual   string   `json:"actual_value,omitempty"`
+	Verified FlexBool `json:"verified"`
+}
+
+// SendingDomain represents a domain configured for sending email
+type SendingDomain struct {
+	Domain   string      `json:"domain"`
+	Verified FlexBool    `json:"verified"`
+	Records  []DNSRecord `json:"records"`
+}
+
+// MissingRecords returns the records that have not been verified yet
+func (d SendingDomain) MissingRecords() []DNSRecord {
+	var missing []DNSRecord
+	for _, record := range d.Records {
+		if !record.Verified {
+			missing = append(missing, record)
+		}
+	}
+	return missing
+}
+
+// GetSendingDomains retrieves the account's sending domains along with the
+// SPF, DKIM and DMARC records each one requires
+func (c *Client) GetSendingDomains() ([]SendingDomain, error) {
+	return c.GetSendingDomainsContext(context.Background())
+}
+
+// GetSendingDomainsContext is like GetSendingDomains but uses ctx for cancellation and deadlines
+func (c *Client) GetSendingDomainsContext(ctx context.Context) ([]SendingDomain, error) {
+	resp, err := c.sendRequest(ctx, "smtp/domains", "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var domains []SendingDomain
+	if err := json.Unmarshal(resp, &domains); err != nil {
+		return nil, fmt.Errorf("failed to parse sending domains: %w", err)
+	}
+
+	return domains, nil
+}
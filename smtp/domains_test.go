@@ -0,0 +1,48 @@
+package smtp
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGetSendingDomainsPartiallyConfigured(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/smtp/domains" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`[{
+			"domain": "example.com",
+			"verified": 0,
+			"records": [
+				{"kind":"spf","type":"TXT","name":"example.com","value":"v=spf1 include:sendpulse.com ~all","actual_value":"v=spf1 include:sendpulse.com ~all","verified":1},
+				{"kind":"dkim","type":"TXT","name":"sign._domainkey.example.com","value":"k=rsa; p=MIGf","verified":0},
+				{"kind":"dmarc","type":"TXT","name":"_dmarc.example.com","value":"v=DMARC1; p=none","actual_value":"v=DMARC1; p=reject","verified":false}
+			]
+		}]`))
+	}))
+
+	domains, err := c.GetSendingDomains()
+	if err != nil {
+		t.Fatalf("GetSendingDomains: %v", err)
+	}
+	if len(domains) != 1 {
+		t.Fatalf("got %d domains, want 1", len(domains))
+	}
+
+	domain := domains[0]
+	if domain.Domain != "example.com" || domain.Verified {
+		t.Errorf("domain = %q verified %v, want unverified example.com", domain.Domain, domain.Verified)
+	}
+	if len(domain.Records) != 3 || !domain.Records[0].Verified {
+		t.Errorf("records = %+v, want 3 with SPF verified", domain.Records)
+	}
+
+	want := []DNSRecord{
+		{Kind: "dkim", Type: "TXT", Name: "sign._domainkey.example.com", Value: "k=rsa; p=MIGf"},
+		{Kind: "dmarc", Type: "TXT", Name: "_dmarc.example.com", Value: "v=DMARC1; p=none", Actual: "v=DMARC1; p=reject"},
+	}
+	if missing := domain.MissingRecords(); !reflect.DeepEqual(missing, want) {
+		t.Errorf("MissingRecords() = %+v, want %+v", missing, want)
+	}
+}
@@ -0,0 +1,114 @@
+package smtp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The Flex types decode the inconsistent representations SendPulse uses for
+// the same kind of field across endpoints. Embed them in structs decoded with
+// SendRawRequestInto.
+
+// FlexBool decodes booleans sent as true/false, 1/0, or strings such as
+// "1", "true" and "yes"
+type FlexBool bool
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *FlexBool) UnmarshalJSON(data []byte) error {
+	s := strings.ToLower(strings.Trim(string(bytes.TrimSpace(data)), `"`))
+	switch s {
+	case "true", "1", "yes", "on":
+		*b = true
+	case "false", "0", "no", "off", "", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (b FlexBool) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bool(b))
+}
+
+// FlexInt decodes integers sent as numbers or numeric strings. Empty strings
+// and null decode to zero.
+type FlexInt int
+
+// UnmarshalJSON implements json.Unmarshaler
+func (i *FlexInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(bytes.TrimSpace(data)), `"`)
+	if s == "" || s == "null" {
+		*i = 0
+		return nil
+	}
+
+	if n, err := strconv.Atoi(s); err == nil {
+		*i = FlexInt(n)
+		return nil
+	}
+
+	// Accept floats with an integral value such as 12.0
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != float64(int(f)) {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*i = FlexInt(f)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (i FlexInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int(i))
+}
+
+// flexTimeLayouts are the date formats accepted by FlexTime, in UTC
+var flexTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// FlexTime decodes timestamps sent as RFC 3339 strings, "2006-01-02 15:04:05"
+// strings, dates, or Unix seconds as numbers or strings. Empty strings and
+// null decode to the zero time.
+type FlexTime struct {
+	time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *FlexTime) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(bytes.TrimSpace(data)), `"`)
+	if s == "" || s == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		t.Time = time.Unix(secs, 0).UTC()
+		return nil
+	}
+
+	for _, layout := range flexTimeLayouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid time %s", data)
+}
+
+// MarshalJSON implements json.Marshaler
+func (t FlexTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Format(time.RFC3339))
+}
@@ -0,0 +1,133 @@
+package smtp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFlexBool(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`1`, true},
+		{`0`, false},
+		{`"1"`, true},
+		{`"0"`, false},
+		{`"true"`, true},
+		{`"Yes"`, true},
+		{`"no"`, false},
+		{`""`, false},
+		{`null`, false},
+	}
+	for _, tt := range tests {
+		var got FlexBool
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if bool(got) != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	var b FlexBool
+	if err := json.Unmarshal([]byte(`"maybe"`), &b); err == nil {
+		t.Error(`Unmarshal("maybe") succeeded, want error`)
+	}
+	if out, _ := json.Marshal(FlexBool(true)); string(out) != "true" {
+		t.Errorf("Marshal = %s, want true", out)
+	}
+}
+
+func TestFlexInt(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{`42`, 42},
+		{`"42"`, 42},
+		{`-7`, -7},
+		{`12.0`, 12},
+		{`"12.0"`, 12},
+		{`""`, 0},
+		{`null`, 0},
+	}
+	for _, tt := range tests {
+		var got FlexInt
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if int(got) != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{`12.5`, `"abc"`} {
+		var i FlexInt
+		if err := json.Unmarshal([]byte(in), &i); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want error", in)
+		}
+	}
+	if out, _ := json.Marshal(FlexInt(5)); string(out) != "5" {
+		t.Errorf("Marshal = %s, want 5", out)
+	}
+}
+
+func TestFlexTime(t *testing.T) {
+	want := time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{`"2024-03-01T10:15:00Z"`, want},
+		{`"2024-03-01T11:15:00+01:00"`, want},
+		{`"2024-03-01 10:15:00"`, want},
+		{`"2024-03-01T10:15:00"`, want},
+		{`"2024-03-01"`, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{`1709288100`, want},
+		{`"1709288100"`, want},
+		{`""`, time.Time{}},
+		{`null`, time.Time{}},
+	}
+	for _, tt := range tests {
+		var got FlexTime
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, got.Time, tt.want)
+		}
+	}
+
+	var ft FlexTime
+	if err := json.Unmarshal([]byte(`"yesterday"`), &ft); err == nil {
+		t.Error(`Unmarshal("yesterday") succeeded, want error`)
+	}
+	if out, _ := json.Marshal(FlexTime{want}); string(out) != `"2024-03-01T10:15:00Z"` {
+		t.Errorf("Marshal = %s", out)
+	}
+	if out, _ := json.Marshal(FlexTime{}); string(out) != "null" {
+		t.Errorf("Marshal(zero) = %s, want null", out)
+	}
+}
+
+func TestFlexTypesInStruct(t *testing.T) {
+	var v struct {
+		Active  FlexBool `json:"active"`
+		Count   FlexInt  `json:"count"`
+		Created FlexTime `json:"created"`
+	}
+	body := []byte(`{"active":"1","count":"17","created":"2024-03-01 10:15:00"}`)
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !v.Active || v.Count != 17 || v.Created.Day() != 1 {
+		t.Errorf("decoded %+v", v)
+	}
+}
@@ -0,0 +1,109 @@
+package smtp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SendingLimits represents the account's SMTP sending limits and current usage
+type SendingLimits struct {
+	HourlyLimit  int `json:"hourly_limit"`
+	SentThisHour int `json:"sent_this_hour"`
+	DailyLimit   int `json:"daily_limit"`
+	SentToday    int `json:"sent_today"`
+
+	// ResetAt is when the exhausted limit resets, if the API reported it
+	ResetAt FlexTime `json:"reset_at"`
+}
+
+// GetSendingLimits retrieves the account's SMTP sending limits and usage
+func (c *Client) GetSendingLimits() (*SendingLimits, error) {
+	return c.GetSendingLimitsContext(context.Background())
+}
+
+// GetSendingLimitsContext is like GetSendingLimits but uses ctx for cancellation and deadlines
+func (c *Client) GetSendingLimitsContext(ctx context.Context) (*SendingLimits, error) {
+	resp, header, err := c.doRequest(ctx, "smtp/limits", "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits SendingLimits
+	if err := json.Unmarshal(resp, &limits); err != nil {
+		return nil, fmt.Errorf("failed to parse sending limits: %w", err)
+	}
+
+	// Fall back to the rate-limit header for the reset time
+	if limits.ResetAt.IsZero() {
+		if secs, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			limits.ResetAt = FlexTime{time.Unix(secs, 0).UTC()}
+		}
+	}
+
+	return &limits, nil
+}
+
+// ShouldThrottle checks current usage against the sending limits and reports
+// whether sending should pause, and for how long, until the limit resets
+func (c *Client) ShouldThrottle() (bool, time.Duration, error) {
+	return c.ShouldThrottleContext(context.Background())
+}
+
+// ShouldThrottleContext is like ShouldThrottle but uses ctx for cancellation and deadlines
+func (c *Client) ShouldThrottleContext(ctx context.Context) (bool, time.Duration, error) {
+	limits, err := c.GetSendingLimitsContext(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+
+	throttle, wait := limits.throttle(time.Now().UTC())
+	return throttle, wait, nil
+}
+
+// TimeUntilQuotaReset returns how long until the sending quota resets, so
+// batch jobs can sleep exactly until they may send again
+func (c *Client) TimeUntilQuotaReset() (time.Duration, error) {
+	return c.TimeUntilQuotaResetContext(context.Background())
+}
+
+// TimeUntilQuotaResetContext is like TimeUntilQuotaReset but uses ctx for cancellation and deadlines
+func (c *Client) TimeUntilQuotaResetContext(ctx context.Context) (time.Duration, error) {
+	limits, err := c.GetSendingLimitsContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return limits.untilReset(time.Now().UTC()), nil
+}
+
+// throttle reports whether the limits are exhausted at now and how long until
+// the exhausted limit resets
+func (l SendingLimits) throttle(now time.Time) (bool, time.Duration) {
+	daily := l.DailyLimit > 0 && l.SentToday >= l.DailyLimit
+	hourly := l.HourlyLimit > 0 && l.SentThisHour >= l.HourlyLimit
+	if !daily && !hourly {
+		return false, 0
+	}
+
+	return true, l.untilReset(now)
+}
+
+// untilReset returns the time from now until the quota resets. Without a
+// reported reset time, limits are assumed to reset on the hour and the daily
+// limit at midnight UTC.
+func (l SendingLimits) untilReset(now time.Time) time.Duration {
+	if !l.ResetAt.IsZero() {
+		return max(l.ResetAt.Sub(now), 0)
+	}
+
+	if l.DailyLimit > 0 && l.SentToday >= l.DailyLimit {
+		midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+		return midnight.Sub(now)
+	}
+
+	nextHour := now.Truncate(time.Hour).Add(time.Hour)
+	return nextHour.Sub(now)
+}
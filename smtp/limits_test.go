@@ -0,0 +1,84 @@
+package smtp
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSendingLimitsThrottle(t *testing.T) {
+	now := time.Date(2024, 3, 1, 22, 15, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		limits   SendingLimits
+		throttle bool
+		wait     time.Duration
+	}{
+		{"under limits", SendingLimits{HourlyLimit: 100, SentThisHour: 10, DailyLimit: 1000, SentToday: 500}, false, 0},
+		{"no limits", SendingLimits{SentThisHour: 10, SentToday: 500}, false, 0},
+		{"hourly exhausted", SendingLimits{HourlyLimit: 100, SentThisHour: 100, DailyLimit: 1000, SentToday: 500}, true, 45 * time.Minute},
+		{"daily exhausted", SendingLimits{HourlyLimit: 100, SentThisHour: 10, DailyLimit: 1000, SentToday: 1000}, true, 105 * time.Minute},
+		{"over daily limit", SendingLimits{DailyLimit: 1000, SentToday: 1200}, true, 105 * time.Minute},
+		{"reported reset", SendingLimits{DailyLimit: 1000, SentToday: 1000, ResetAt: FlexTime{now.Add(3 * time.Hour)}}, true, 3 * time.Hour},
+		{"reset passed", SendingLimits{DailyLimit: 1000, SentToday: 1000, ResetAt: FlexTime{now.Add(-time.Minute)}}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle, wait := tt.limits.throttle(now)
+			if throttle != tt.throttle || wait != tt.wait {
+				t.Errorf("throttle = %v, %v; want %v, %v", throttle, wait, tt.throttle, tt.wait)
+			}
+		})
+	}
+}
+
+func TestShouldThrottle(t *testing.T) {
+	reset := time.Now().Add(2 * time.Hour).Unix()
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/smtp/limits" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		writeJSON(t, w, SendingLimits{DailyLimit: 100, SentToday: 100})
+	}))
+
+	throttle, wait, err := c.ShouldThrottle()
+	if err != nil {
+		t.Fatalf("ShouldThrottle: %v", err)
+	}
+	if !throttle || wait < 119*time.Minute || wait > 2*time.Hour {
+		t.Errorf("ShouldThrottle = %v, %v; want true, about 2h", throttle, wait)
+	}
+}
+
+func TestTimeUntilQuotaReset(t *testing.T) {
+	reset := time.Now().Add(90 * time.Minute).UTC()
+	tests := []struct {
+		name   string
+		body   string
+		header string
+	}{
+		{"reset_at field", `{"daily_limit":100,"sent_today":100,"reset_at":"` + reset.Format(time.RFC3339) + `"}`, ""},
+		{"rate-limit header", `{"daily_limit":100,"sent_today":100}`, strconv.FormatInt(reset.Unix(), 10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "" {
+					w.Header().Set("X-RateLimit-Reset", tt.header)
+				}
+				w.Write([]byte(tt.body))
+			}))
+
+			wait, err := c.TimeUntilQuotaReset()
+			if err != nil {
+				t.Fatalf("TimeUntilQuotaReset: %v", err)
+			}
+			if wait < 89*time.Minute || wait > 90*time.Minute {
+				t.Errorf("wait = %v, want about 90m", wait)
+			}
+		})
+	}
+}
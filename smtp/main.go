@@ -2,20 +2,74 @@ package smtp
 
 import (
 	"bytes"
-	"crypto/md5"
+	"context"
 	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	APIUrl = "https://api.sendpulse.com"
+
+	// RemoveEmailsChunkSize is the maximum number of emails removed per request
+	RemoveEmailsChunkSize = 100
+
+	// AddEmailsChunkSize is the default number of emails added per request
+	AddEmailsChunkSize = 100
+
+	// AddPhonesChunkSize is the maximum number of phones added per request
+	AddPhonesChunkSize = 100
+
+	// DefaultMaxAttachmentSize is the default limit on the combined encoded
+	// size of a send's attachments
+	DefaultMaxAttachmentSize = 25 << 20
+
+	// DefaultCharset and DefaultContentType are applied to SMTP emails that
+	// don't specify their own
+	DefaultCharset     = "UTF-8"
+	DefaultContentType = "text/html"
+
+	// DefaultDiscoverSampleSize is the default number of contacts inspected
+	// by DiscoverVariables
+	DefaultDiscoverSampleSize = 1000
+
+	// deleteConcurrency bounds the number of concurrent campaign deletions
+	deleteConcurrency = 5
+
+	// DefaultTokenExpirySkew is how long before expiry a token is refreshed
+	// when the client's TokenExpirySkew is zero
+	DefaultTokenExpirySkew = 60 * time.Second
+
+	// campaignPageSize and emailPageSize are the default page sizes used
+	// when iterating over campaigns and address books, and over emails
+	campaignPageSize = 100
+	emailPageSize    = 100
+
+	// maxErrorCodeRetries and errorCodeRetryDelay control retries of
+	// responses carrying a retryable API error code
+	maxErrorCodeRetries = 3
+	errorCodeRetryDelay = time.Second
+
+	// maxAuthRetries is how many times a request rejected with 401 is
+	// retried with a refreshed token
+	maxAuthRetries = 1
 )
 
 // Error messages
@@ -25,13 +79,63 @@ const (
 	ErrInvalidCredentials = "Invalid credentials"
 )
 
-// Client represents the SendPulse API client
+// ErrTokenRefreshFailed is returned, wrapping the cause, when an expired
+// token can't be replaced
+var ErrTokenRefreshFailed = errors.New("failed to refresh token")
+
+// ErrClientPaused is returned by send methods while the client is paused
+var ErrClientPaused = errors.New("client is paused")
+
+// ErrAttachmentsTooLarge is returned when the combined attachment size exceeds
+// the client's MaxAttachmentSize
+var ErrAttachmentsTooLarge = errors.New("attachments exceed the maximum total size")
+
+// Client represents the SendPulse API client. A Client is safe for concurrent
+// use by multiple goroutines: token reads and refreshes are synchronized, and
+// only one refresh runs at a time while other callers wait for its token.
+// Exported fields must be set before the client is shared; the Set methods
+// may be called at any time.
 type Client struct {
 	UserID       string
 	Secret       string
 	TokenStorage string
 	Token        string
 	httpClient   *http.Client
+	baseURL      string
+	eventsURL    string
+	logger       *slog.Logger
+	tokenStore   TokenStore
+
+	// RetryPolicy controls retries of transient failures
+	RetryPolicy RetryPolicy
+
+	// MaxAttachmentSize limits the combined base64-encoded size of
+	// attachments in a single send. Zero disables the check.
+	MaxAttachmentSize int64
+
+	// SkipBlacklistedPhones drops blacklisted numbers from SMS sends
+	SkipBlacklistedPhones bool
+
+	// Timezone is the account timezone used to interpret dates returned by
+	// the API. Nil means UTC.
+	Timezone *time.Location
+
+	// TokenExpirySkew is how long before its expiry the token is refreshed
+	// ahead of a request. Zero means DefaultTokenExpirySkew.
+	TokenExpirySkew time.Duration
+
+	// mu guards Token and the fields in this group
+	mu                  sync.RWMutex
+	lastTokenResponse   *TokenResponse
+	tokenExpiresAt      time.Time
+	defaultSender       *Contact
+	retryableErrorCodes map[int]bool
+
+	// refreshMu serializes token fetches so concurrent callers share one
+	refreshMu sync.Mutex
+
+	limiter atomic.Pointer[rateLimiter]
+	paused  atomic.Bool
 }
 
 // ErrorResponse represents an API error response
@@ -41,25 +145,172 @@ type ErrorResponse struct {
 	ErrorCode int    `json:"error_code,omitempty"`
 }
 
+// APIError is returned when the API rejects a request, either with a non-2xx
+// status or with an error response body. Use errors.As to inspect it.
+type APIError struct {
+	StatusCode int
+	ErrorCode  int
+	Message    string
+	Body       []byte
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+	if e.ErrorCode != 0 {
+		msg += fmt.Sprintf(" (error code %d)", e.ErrorCode)
+	}
+	return msg
+}
+
+// newAPIError builds an APIError from a response, taking the message and
+// error code from the body when it is an error response. Responses with an
+// empty or non-JSON body, such as a proxy's HTML error page, use the status
+// text as the message.
+func newAPIError(status int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: status, Body: body}
+
+	var errResp struct {
+		ErrorResponse
+		Description string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &errResp); err != nil {
+		apiErr.Message = http.StatusText(status)
+		return apiErr
+	}
+
+	apiErr.ErrorCode = errResp.ErrorCode
+	apiErr.Message = errResp.Message
+	if apiErr.Message == "" {
+		apiErr.Message = errResp.Description
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	return apiErr
+}
+
+// checkResponse returns an APIError for non-2xx responses and for 2xx
+// responses whose body reports is_error
+func checkResponse(status int, body []byte) error {
+	if status < 200 || status > 299 {
+		return newAPIError(status, body)
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil
+	}
+
+	var errResp ErrorResponse
+	if err := json.Unmarshal(trimmed, &errResp); err == nil && errResp.IsError {
+		return newAPIError(status, body)
+	}
+	return nil
+}
+
 // TokenResponse represents the OAuth token response
 type TokenResponse struct {
 	AccessToken string `json:"access_token"`
 	TokenType   string `json:"token_type"`
 	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// String formats the token response without exposing the access token
+func (t TokenResponse) String() string {
+	return fmt.Sprintf("{AccessToken:[REDACTED] TokenType:%s ExpiresIn:%d Scope:%s}", t.TokenType, t.ExpiresIn, t.Scope)
+}
+
+// GoString formats the token response for %#v without exposing the access token
+func (t TokenResponse) GoString() string {
+	return "smtp.TokenResponse" + t.String()
 }
 
 // AddressBook represents an address book
 type AddressBook struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
+
+	// Email counts are reported by GetBookInfo and ListAddressBooks, and
+	// are zero when the API leaves them out
+	AllEmailQty      int `json:"all_email_qty,omitempty"`
+	ActiveEmailQty   int `json:"active_email_qty,omitempty"`
+	InactiveEmailQty int `json:"inactive_email_qty,omitempty"`
+}
+
+// BookGrowthPoint represents the change in an address book's subscribers on one day
+type BookGrowthPoint struct {
+	Date    time.Time
+	Added   int
+	Removed int
+	Delta   int
+}
+
+// BookVariable represents a variable definition in an address book
+type BookVariable struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// BookExport bundles the contacts of an address book with its variable schema
+type BookExport struct {
+	BookID    int            `json:"book_id"`
+	Emails    []Email        `json:"emails"`
+	Variables []BookVariable `json:"variables"`
 }
 
+// Email statuses within an address book
+const (
+	EmailStatusNew          = 0
+	EmailStatusActive       = 1
+	EmailStatusUnsubscribed = 2
+	EmailStatusUnconfirmed  = 3
+)
+
 // Email represents an email address with variables
 type Email struct {
+	Email         string                 `json:"email"`
+	Status        int                    `json:"status,omitempty"`
+	StatusExplain string                 `json:"status_explain,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// EmailBookInfo represents an email address's membership in one address book
+type EmailBookInfo struct {
+	BookID    int                    `json:"book_id"`
 	Email     string                 `json:"email"`
+	Status    int                    `json:"status"`
 	Variables map[string]interface{} `json:"variables,omitempty"`
 }
 
+// EmailCampaignStats summarizes the campaign activity of an email address
+// across every address book
+type EmailCampaignStats struct {
+	Sent        int
+	Opened      int
+	Clicked     int
+	Blacklisted bool
+
+	// LastActivity is when the address last received, opened or clicked a
+	// campaign, or the zero time if it never has
+	LastActivity time.Time
+
+	// Books lists the address books containing the address, with its
+	// status in each
+	Books []EmailBookInfo
+}
+
+// MergeStrategy decides which value wins when a variable is set in several books
+type MergeStrategy int
+
+// Merge strategies
+const (
+	MergeLastWins MergeStrategy = iota
+	MergeFirstWins
+)
+
 // Campaign represents an email campaign
 type Campaign struct {
 	ID          int    `json:"id"`
@@ -68,6 +319,145 @@ type Campaign struct {
 	SenderName  string `json:"sender_name"`
 	SenderEmail string `json:"sender_email"`
 	Subject     string `json:"subject"`
+	SendDate    string `json:"send_date,omitempty"`
+	AllEmailQty int    `json:"all_email_qty,omitempty"`
+	GroupID     int    `json:"group_id,omitempty"`
+
+	Message    *CampaignMessage    `json:"message,omitempty"`
+	Statistics []CampaignStatistic `json:"statistics,omitempty"`
+}
+
+// CampaignStatistic is one delivery counter of a campaign, such as the number
+// of opened or bounced emails
+type CampaignStatistic struct {
+	Code    int    `json:"code"`
+	Count   int    `json:"count"`
+	Explain string `json:"explain"`
+}
+
+// GroupStats holds the statistics of every campaign in a group added together
+type GroupStats struct {
+	GroupID   int
+	Campaigns int
+	Sent      int
+
+	// Statistics maps each counter's description to its total across the group
+	Statistics map[string]int
+}
+
+// CampaignMessage represents the message content of a campaign
+type CampaignMessage struct {
+	SenderName  string `json:"sender_name"`
+	SenderEmail string `json:"sender_email"`
+	Subject     string `json:"subject"`
+	Body        string `json:"body"`
+	ListID      int    `json:"list_id"`
+}
+
+// mergeFieldPattern matches {{variable}} placeholders in a message body
+var mergeFieldPattern = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+
+// CampaignSummary is a lightweight view of a campaign for dashboards
+type CampaignSummary struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	SentCount int    `json:"sent_count"`
+}
+
+// Campaign recipient statuses accepted by GetCampaignRecipients
+const (
+	RecipientDelivered = "delivered"
+	RecipientOpened    = "opened"
+	RecipientClicked   = "clicked"
+	RecipientBounced   = "bounced"
+)
+
+// RecipientStatus represents the delivery status of one campaign recipient
+type RecipientStatus struct {
+	Email     string   `json:"email"`
+	Status    string   `json:"status"`
+	Opened    FlexBool `json:"opened"`
+	Clicked   FlexBool `json:"clicked"`
+	UpdatedAt FlexTime `json:"updated_at"`
+}
+
+// Contact represents a named email address used as a sender or recipient
+type Contact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+}
+
+// SMTPEmail represents a transactional email sent via SMTP
+type SMTPEmail struct {
+	From    Contact   `json:"from"`
+	To      []Contact `json:"to"`
+	CC      []Contact `json:"cc,omitempty"`
+	BCC     []Contact `json:"bcc,omitempty"`
+	ReplyTo *Contact  `json:"reply_to,omitempty"`
+	Subject string    `json:"subject"`
+	HTML    string    `json:"html,omitempty"`
+	Text    string    `json:"text,omitempty"`
+
+	// Charset and ContentType describe the body encoding. They default to
+	// DefaultCharset and DefaultContentType when empty.
+	Charset     string `json:"charset,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+
+	// TrackOpens and TrackClicks override the account's tracking settings
+	// when set
+	TrackOpens  *bool `json:"track_opens,omitempty"`
+	TrackClicks *bool `json:"track_clicks,omitempty"`
+
+	// TemplateID sends a stored template instead of HTML, filled in with
+	// TemplateVariables
+	TemplateID        int                    `json:"-"`
+	TemplateVariables map[string]interface{} `json:"-"`
+
+	// Attachments are serialized as regular or inline attachments
+	// depending on whether they carry a ContentID
+	Attachments []Attachment `json:"-"`
+}
+
+// SMTPMessage is the typed message accepted by SendMessage
+type SMTPMessage = SMTPEmail
+
+// Attachment represents a file attached to an SMTP email. Attachments with a
+// ContentID are sent inline and can be referenced from HTML as "cid:<ContentID>".
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+	ContentID   string
+}
+
+// smtpEmailPayload is the wire representation of an SMTPEmail
+type smtpEmailPayload struct {
+	SMTPEmail
+	Template          *templatePayload          `json:"template,omitempty"`
+	AttachmentsBinary map[string]string         `json:"attachments_binary,omitempty"`
+	InlineAttachments []inlineAttachmentPayload `json:"inline_attachments,omitempty"`
+}
+
+// templatePayload is the wire representation of a template reference
+type templatePayload struct {
+	ID        int                    `json:"id"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// inlineAttachmentPayload is the wire representation of an inline attachment
+type inlineAttachmentPayload struct {
+	ContentID   string `json:"cid"`
+	Filename    string `json:"name"`
+	ContentType string `json:"type,omitempty"`
+	Content     string `json:"content"`
+}
+
+// SMTPSendResult represents the result of an SMTP send
+type SMTPSendResult struct {
+	Result    bool   `json:"result"`
+	ID        string `json:"id"`
+	Recipient string `json:"-"`
 }
 
 // SMSCampaign represents an SMS campaign
@@ -76,6 +466,55 @@ type SMSCampaign struct {
 	Sender string `json:"sender"`
 	Body   string `json:"body"`
 	Status string `json:"status"`
+
+	// SendDate and Recipients are reported by GetSMSCampaignInfo and
+	// ListSMSCampaigns
+	SendDate   FlexTime `json:"send_date"`
+	Recipients FlexInt  `json:"all_recipients"`
+}
+
+// SMSSendResult represents the result of an SMS send
+type SMSSendResult struct {
+	CampaignID int     `json:"campaign_id"`
+	Cost       float64 `json:"campaign_cost"`
+	Currency   string  `json:"currency"`
+}
+
+// SMS delivery statuses
+const (
+	SMSStatusPending   = "pending"
+	SMSStatusSent      = "sent"
+	SMSStatusDelivered = "delivered"
+	SMSStatusFailed    = "failed"
+)
+
+// SMSStats counts the phones of an SMS campaign by delivery status. Phones
+// sent but not yet confirmed count as pending.
+type SMSStats struct {
+	CampaignID int
+	Total      int
+	Delivered  int
+	Failed     int
+	Pending    int
+}
+
+// SMSStatus represents the delivery status of a single SMS
+type SMSStatus struct {
+	MessageID string
+	Phone     string
+	Status    string
+	UpdatedAt time.Time
+}
+
+// AddPhonesResult represents the outcome of adding phones to an address book
+type AddPhonesResult struct {
+	Added    int
+	Failed   int
+	Failures map[string]string // phone -> rejection reason
+
+	// Unsent holds the phones of chunks whose request failed, so they can
+	// be retried
+	Unsent []string
 }
 
 // Phone represents a phone number with variables
@@ -84,539 +523,3054 @@ type Phone struct {
 	Variables map[string]interface{} `json:"variables,omitempty"`
 }
 
-// NewClient creates a new SendPulse API client
-func NewClient(userID, secret, tokenStorage string) *Client {
-	return &Client{
-		UserID:       userID,
-		Secret:       secret,
-		TokenStorage: tokenStorage,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// NewClient creates a new SendPulse API client that stores its token in a
+// file under tokenStorage
+func NewClient(userID, secret, tokenStorage string, opts ...ClientOption) *Client {
+	c := NewClientWithStore(userID, secret, NewFileTokenStore(tokenStorage, userID, secret))
+	c.TokenStorage = tokenStorage
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
-// Init initializes the client and loads/retrieves the access token
-func (c *Client) Init() error {
-	// Create token storage directory if it doesn't exist
-	if err := os.MkdirAll(c.TokenStorage, 0755); err != nil {
-		return fmt.Errorf("failed to create token storage directory: %w", err)
+// NewClientWithOptions creates a new SendPulse API client configured by opts.
+// Unless WithTokenStorage is given, the token is kept in memory only.
+func NewClientWithOptions(userID, secret string, opts ...ClientOption) *Client {
+	c := NewClientWithStore(userID, secret, &MemoryTokenStore{})
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
 
-	// Generate hash for token filename
-	hashName := fmt.Sprintf("%x", md5.Sum([]byte(c.UserID+"::"+c.Secret)))
-	tokenPath := filepath.Join(c.TokenStorage, hashName)
+// ClientOption configures a Client on creation
+type ClientOption func(*Client)
 
-	// Try to load existing token
-	if tokenData, err := os.ReadFile(tokenPath); err == nil {
-		c.Token = string(tokenData)
+// WithHTTPClient sends requests through httpClient instead of a default
+// client with a 30 second timeout, for example to use a proxy or custom TLS
+// configuration. A nil httpClient keeps the default.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
 	}
+}
 
-	// If no token or token is empty, get a new one
-	if c.Token == "" {
-		return c.getToken()
+// WithTimeout sets the overall timeout of each HTTP request. It applies to a
+// copy of the HTTP client, so a client passed to WithHTTPClient is not modified.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		httpClient := *c.httpClient
+		httpClient.Timeout = timeout
+		c.httpClient = &httpClient
 	}
+}
 
-	return nil
+// WithLogger logs each request's method, path, status and duration at debug
+// level and failed requests at error level. Request headers and bodies, and
+// with them credentials and tokens, are never logged. Clients log nothing by
+// default.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		if logger == nil {
+			logger = slog.New(slog.DiscardHandler)
+		}
+		c.logger = logger
+	}
 }
 
-// getToken retrieves a new access token from the API
-func (c *Client) getToken() error {
-	data := map[string]string{
-		"grant_type":    "client_credentials",
-		"client_id":     c.UserID,
-		"client_secret": c.Secret,
+// WithBaseURL sends API requests to baseURL instead of APIUrl, for example
+// a proxy or a test server
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
 	}
+}
 
-	resp, err := c.sendRequest("oauth/access_token", "POST", data, false)
-	if err != nil {
-		return err
+// WithEventsBaseURL sends Automation360 events to baseURL instead of
+// EventsURL, for example a test server
+func WithEventsBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.eventsURL = strings.TrimRight(baseURL, "/")
 	}
+}
 
-	var tokenResp TokenResponse
-	if err := json.Unmarshal(resp, &tokenResp); err != nil {
-		return fmt.Errorf("failed to parse token response: %w", err)
+// WithRetryPolicy sets how the client retries 429 responses, 5xx responses
+// and network errors. A zero MaxRetries disables retries.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.RetryPolicy = policy
 	}
+}
 
-	c.Token = tokenResp.AccessToken
+// WithTokenStorage persists the client's token in storage instead of the
+// token storage directory
+func WithTokenStorage(storage TokenStorage) ClientOption {
+	return func(c *Client) {
+		c.tokenStore = &keyedTokenStore{storage: storage, key: tokenKey(c.UserID, c.Secret)}
+	}
+}
 
-	// Save token to file
-	hashName := fmt.Sprintf("%x", md5.Sum([]byte(c.UserID+"::"+c.Secret)))
-	tokenPath := filepath.Join(c.TokenStorage, hashName)
-	return os.WriteFile(tokenPath, []byte(c.Token), 0644)
+// NewClientWithStore creates a new SendPulse API client that persists its
+// token in store
+func NewClientWithStore(userID, secret string, store TokenStore) *Client {
+	return &Client{
+		UserID:     userID,
+		Secret:     secret,
+		baseURL:    APIUrl,
+		eventsURL:  EventsURL,
+		logger:     slog.New(slog.DiscardHandler),
+		tokenStore: store,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		RetryPolicy:       DefaultRetryPolicy,
+		MaxAttachmentSize: DefaultMaxAttachmentSize,
+	}
 }
 
-// sendRequest sends an HTTP request to the API
-func (c *Client) sendRequest(path, method string, data interface{}, useToken bool) ([]byte, error) {
-	url := fmt.Sprintf("%s/%s", APIUrl, path)
+// Validate checks the client's configuration and returns all problems found
+func (c *Client) Validate() error {
+	var errs []error
 
-	var body io.Reader
-	if data != nil {
-		jsonData, err := json.Marshal(data)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request data: %w", err)
-		}
-		body = bytes.NewBuffer(jsonData)
+	if c.UserID == "" {
+		errs = append(errs, fmt.Errorf("empty user id"))
 	}
-
-	req, err := http.NewRequest(method, url, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if c.Secret == "" {
+		errs = append(errs, fmt.Errorf("empty secret"))
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if useToken && c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
+	// File-backed stores may be wrapped by the TokenStorage adapter
+	var store interface{} = c.tokenStore
+	if keyed, ok := c.tokenStore.(*keyedTokenStore); ok {
+		store = keyed.storage
+		if store == nil {
+			errs = append(errs, fmt.Errorf("no token storage configured"))
+		}
+	} else if store == nil {
+		errs = append(errs, fmt.Errorf("no token store configured"))
+	}
+	if files, ok := store.(tokenDirStore); ok {
+		if dir := files.tokenDir(); dir == "" {
+			errs = append(errs, fmt.Errorf("empty token storage path"))
+		} else if err := checkWritableDir(dir); err != nil {
+			errs = append(errs, fmt.Errorf("token storage is not writable: %w", err))
+		}
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+	if u, err := url.Parse(c.baseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("invalid base URL %q", c.baseURL))
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
+	return errors.Join(errs...)
+}
 
-	// Handle 401 Unauthorized - token might be expired
-	if resp.StatusCode == 401 {
-		var errResp ErrorResponse
-		json.Unmarshal(respBody, &errResp)
+// checkWritableDir verifies that files can be created in dir. A dir that
+// doesn't exist yet is checked through its nearest existing ancestor, where
+// it would be created, so the check leaves the filesystem unchanged apart
+// from a probe file it removes.
+func checkWritableDir(dir string) error {
+	if err := checkTokenDir(dir); err != nil {
+		return err
+	}
 
-		if strings.Contains(string(respBody), "invalid_client") {
-			return nil, fmt.Errorf(ErrInvalidCredentials)
+	existing := filepath.Clean(dir)
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", existing)
+			}
+			break
 		}
-
-		// Try to refresh token and retry request
-		if err := c.getToken(); err != nil {
-			return nil, fmt.Errorf("failed to refresh token: %w", err)
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
 		}
 
-		// Retry the request with new token
-		return c.sendRequest(path, method, data, true)
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return err
+		}
+		existing = parent
 	}
 
-	return respBody, nil
+	f, err := os.CreateTemp(existing, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
 }
 
-// Address Books
+// Init initializes the client and loads/retrieves the access token
+func (c *Client) Init() error {
+	return c.InitContext(context.Background())
+}
 
-// ListAddressBooks retrieves the list of address books
-func (c *Client) ListAddressBooks(limit, offset int) ([]AddressBook, error) {
-	params := make(map[string]interface{})
-	if limit > 0 {
-		params["limit"] = limit
+// InitContext is like Init but uses ctx for cancellation and deadlines
+func (c *Client) InitContext(ctx context.Context) error {
+	// Try to load existing token
+	tokenData, err := c.tokenStore.Get()
+	if errors.Is(err, ErrTokenStorageNotDir) {
+		return err
 	}
-	if offset > 0 {
-		params["offset"] = offset
+	if err == nil && tokenData != "" {
+		c.loadToken([]byte(tokenData))
 	}
 
-	resp, err := c.sendRequest("addressbooks", "GET", params, true)
-	if err != nil {
-		return nil, err
+	// If no token or token is empty or about to expire, get a new one
+	if c.currentToken() == "" {
+		return c.refreshToken(ctx, "")
 	}
 
-	var books []AddressBook
-	if err := json.Unmarshal(resp, &books); err != nil {
-		return nil, fmt.Errorf("failed to parse address books: %w", err)
-	}
+	return c.refreshTokenIfExpiring(ctx, c.tokenExpirySkew())
+}
 
-	return books, nil
+// storedToken is the persisted form of an access token
+type storedToken struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
 }
 
-// CreateAddressBook creates a new address book
-func (c *Client) CreateAddressBook(name string) (*AddressBook, error) {
-	if name == "" {
-		return nil, fmt.Errorf("empty book name")
+// loadToken restores a persisted token. Tokens stored before expiry tracking
+// are bare and are loaded with an unknown expiry.
+func (c *Client) loadToken(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var stored storedToken
+	if err := json.Unmarshal(data, &stored); err == nil && stored.AccessToken != "" {
+		c.Token = stored.AccessToken
+		c.tokenExpiresAt = stored.ExpiresAt
+		return
 	}
+	c.Token = strings.TrimSpace(string(data))
+}
 
-	data := map[string]string{"bookName": name}
-	resp, err := c.sendRequest("addressbooks", "POST", data, true)
-	if err != nil {
-		return nil, err
+// getToken retrieves a new access token from the API
+func (c *Client) getToken(ctx context.Context) error {
+	data := map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     c.UserID,
+		"client_secret": c.Secret,
 	}
 
-	var book AddressBook
-	if err := json.Unmarshal(resp, &book); err != nil {
-		return nil, fmt.Errorf("failed to parse address book: %w", err)
+	resp, err := c.sendRequest(ctx, "oauth/access_token", "POST", data, false)
+	if err != nil {
+		return err
 	}
 
-	return &book, nil
-}
+	var tokenResp TokenResponse
+	if err := json.Unmarshal(resp, &tokenResp); err != nil {
+		return fmt.Errorf("failed to parse token response: %w", err)
+	}
 
-// EditAddressBook edits an address book name
-func (c *Client) EditAddressBook(id int, name string) error {
-	if id == 0 || name == "" {
-		return fmt.Errorf("empty book name or book id")
+	var expiresAt time.Time
+	if tokenResp.ExpiresIn > 0 {
+		expiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
 	}
 
-	data := map[string]string{"name": name}
-	_, err := c.sendRequest(fmt.Sprintf("addressbooks/%d", id), "PUT", data, true)
-	return err
-}
+	c.mu.Lock()
+	c.Token = tokenResp.AccessToken
+	c.lastTokenResponse = &tokenResp
+	c.tokenExpiresAt = expiresAt
+	c.mu.Unlock()
 
-// RemoveAddressBook removes an address book
-func (c *Client) RemoveAddressBook(id int) error {
-	if id == 0 {
-		return fmt.Errorf("empty book id")
+	// Save token and its expiry to the store
+	stored, err := json.Marshal(storedToken{AccessToken: tokenResp.AccessToken, ExpiresAt: expiresAt})
+	if err != nil {
+		return fmt.Errorf("failed to serialize token: %w", err)
 	}
-
-	_, err := c.sendRequest(fmt.Sprintf("addressbooks/%d", id), "DELETE", nil, true)
-	return err
+	return c.tokenStore.Set(string(stored))
 }
 
-// GetBookInfo retrieves information about an address book
-func (c *Client) GetBookInfo(id int) (*AddressBook, error) {
-	if id == 0 {
-		return nil, fmt.Errorf("empty book id")
+// refreshTokenIfExpiring fetches a new token if the current one expires
+// within margin. Tokens with an unknown expiry are left alone.
+func (c *Client) refreshTokenIfExpiring(ctx context.Context, margin time.Duration) error {
+	if !c.tokenExpiring(margin) {
+		return nil
 	}
 
-	resp, err := c.sendRequest(fmt.Sprintf("addressbooks/%d", id), "GET", nil, true)
-	if err != nil {
-		return nil, err
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	// Another caller may have refreshed while we waited for the lock
+	if !c.tokenExpiring(margin) {
+		return nil
 	}
+	return c.getToken(ctx)
+}
 
-	var book AddressBook
-	if err := json.Unmarshal(resp, &book); err != nil {
-		return nil, fmt.Errorf("failed to parse address book: %w", err)
+// tokenExpirySkew returns the configured refresh window before token expiry
+func (c *Client) tokenExpirySkew() time.Duration {
+	if c.TokenExpirySkew <= 0 {
+		return DefaultTokenExpirySkew
 	}
+	return c.TokenExpirySkew
+}
 
-	return &book, nil
+// tokenExpiring reports whether the current token has a known expiry within
+// margin
+func (c *Client) tokenExpiring(margin time.Duration) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.tokenExpiresAt.IsZero() && time.Until(c.tokenExpiresAt) <= margin
 }
 
-// Email Management
+// refreshToken fetches a new token unless the current one no longer matches
+// stale, meaning another caller already replaced it. Concurrent callers wait
+// for a single fetch instead of each requesting their own token.
+func (c *Client) refreshToken(ctx context.Context, stale string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
 
-// GetEmailsFromBook retrieves email addresses from an address book
-func (c *Client) GetEmailsFromBook(id int) ([]Email, error) {
-	if id == 0 {
-		return nil, fmt.Errorf("empty book id")
+	if token := c.currentToken(); token != "" && token != stale {
+		return nil
 	}
+	return c.getToken(ctx)
+}
 
-	resp, err := c.sendRequest(fmt.Sprintf("addressbooks/%d/emails", id), "GET", nil, true)
-	if err != nil {
-		return nil, err
+// currentToken returns the access token used to authorize requests
+func (c *Client) currentToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Token
+}
+
+// tokenContextKey is the context key for per-request token overrides
+type tokenContextKey struct{}
+
+// WithToken returns a context that makes requests use token instead of the
+// client's own. The token is not cached, persisted or refreshed; an expired
+// override fails with a 401 APIError. Use it to act on behalf of another
+// account with a shared client.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, token)
+}
+
+// tokenFromContext returns the token override carried by ctx, if any
+func tokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenContextKey{}).(string)
+	return token, ok && token != ""
+}
+
+// LastTokenResponse returns a copy of the most recent successful token
+// response, or nil if no token has been fetched by this client
+func (c *Client) LastTokenResponse() *TokenResponse {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.lastTokenResponse == nil {
+		return nil
+	}
+	resp := *c.lastTokenResponse
+	return &resp
+}
+
+// sendRequest sends an HTTP request to the API
+func (c *Client) sendRequest(ctx context.Context, path, method string, data interface{}, useToken bool) ([]byte, error) {
+	body, _, err := c.doRequest(ctx, path, method, data, useToken)
+	return body, err
+}
+
+// doRequest sends an HTTP request to the API and returns the response body
+// along with its headers. Transient failures are retried according to the
+// client's RetryPolicy, and responses carrying a retryable error code are
+// retried as well. Requests that ultimately fail are logged at error level.
+func (c *Client) doRequest(ctx context.Context, path, method string, data interface{}, useToken bool) ([]byte, http.Header, error) {
+	return c.doRequestTo(ctx, c.baseURL, path, method, data, useToken)
+}
+
+// doRequestTo is like doRequest but sends the request to path under baseURL,
+// for services hosted outside the main API
+func (c *Client) doRequestTo(ctx context.Context, baseURL, path, method string, data interface{}, useToken bool) (_ []byte, _ http.Header, err error) {
+	defer func() {
+		if err != nil {
+			c.logger.LogAttrs(ctx, slog.LevelError, "request failed",
+				slog.String("method", method), slog.String("path", path), slog.Any("error", err))
+		}
+	}()
+
+	// Refresh ahead of expiry rather than waiting for a 401
+	_, overridden := tokenFromContext(ctx)
+	if useToken && !overridden {
+		if err := c.refreshTokenIfExpiring(ctx, c.tokenExpirySkew()); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrTokenRefreshFailed, err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if limiter := c.limiter.Load(); limiter != nil {
+			if err := limiter.wait(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		body, resp, err := c.doRequestOnce(ctx, baseURL, path, method, data, useToken)
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if !isIdempotent(method) || !isTransportError(ctx, err) || attempt >= c.RetryPolicy.MaxRetries {
+				return nil, nil, err
+			}
+			delay = c.RetryPolicy.backoff(attempt)
+
+		case isRetryableStatus(resp.StatusCode):
+			retryable := resp.StatusCode == http.StatusTooManyRequests || isIdempotent(method)
+			if !retryable || attempt >= c.RetryPolicy.MaxRetries {
+				return nil, nil, newAPIError(resp.StatusCode, body)
+			}
+			delay = c.RetryPolicy.backoff(attempt)
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+
+		case attempt < maxErrorCodeRetries && c.hasRetryableErrorCode(body):
+			delay = errorCodeRetryDelay
+
+		default:
+			if err := checkResponse(resp.StatusCode, body); err != nil {
+				return nil, nil, err
+			}
+			return body, resp.Header, nil
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// Pause makes every method that sends email, SMS or campaigns, as well as
+// raw requests other than GET, return ErrClientPaused without contacting the
+// API, halting all outbound traffic. Read methods keep working.
+func (c *Client) Pause() {
+	c.paused.Store(true)
+}
+
+// Resume lets send methods reach the API again after Pause
+func (c *Client) Resume() {
+	c.paused.Store(false)
+}
+
+// Paused reports whether the client is paused
+func (c *Client) Paused() bool {
+	return c.paused.Load()
+}
+
+// SetRateLimit throttles outbound requests to rps requests per second,
+// allowing bursts of up to burst requests. Retries count against the limit.
+// A non-positive rps removes the limit.
+func (c *Client) SetRateLimit(rps int, burst int) {
+	if rps <= 0 {
+		c.limiter.Store(nil)
+		return
+	}
+	c.limiter.Store(newRateLimiter(rps, max(burst, 1)))
+}
+
+// SetRetryableErrorCodes sets the API error codes that are treated as
+// transient. Responses carrying one of these codes are retried, even when
+// returned with a 2xx status.
+func (c *Client) SetRetryableErrorCodes(codes []int) {
+	retryable := make(map[int]bool, len(codes))
+	for _, code := range codes {
+		retryable[code] = true
+	}
+
+	c.mu.Lock()
+	c.retryableErrorCodes = retryable
+	c.mu.Unlock()
+}
+
+// hasRetryableErrorCode reports whether body is an error response with one of
+// the retryable error codes
+func (c *Client) hasRetryableErrorCode(body []byte) bool {
+	c.mu.RLock()
+	retryable := c.retryableErrorCodes
+	c.mu.RUnlock()
+
+	if len(retryable) == 0 {
+		return false
+	}
+
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil {
+		return false
+	}
+
+	return errResp.IsError && retryable[errResp.ErrorCode]
+}
+
+// doRequestOnce sends a single HTTP request to the API, refreshing the token
+// and retrying once on 401. The returned response's body is already consumed.
+func (c *Client) doRequestOnce(ctx context.Context, baseURL, path, method string, data interface{}, useToken bool) ([]byte, *http.Response, error) {
+	return c.doRequestAttempt(ctx, baseURL, path, method, data, useToken, 0)
+}
+
+// doRequestAttempt implements doRequestOnce. authRetries counts the token
+// refreshes already made for this request; once it reaches maxAuthRetries a
+// 401 is returned as an APIError instead of refreshing again.
+func (c *Client) doRequestAttempt(ctx context.Context, baseURL, path, method string, data interface{}, useToken bool, authRetries int) ([]byte, *http.Response, error) {
+	endpoint := fmt.Sprintf("%s/%s", baseURL, path)
+
+	// GET parameters go in the query string, the API ignores GET bodies
+	if method == "GET" {
+		if query, ok := encodeQuery(data); ok {
+			if encoded := query.Encode(); encoded != "" {
+				sep := "?"
+				if strings.Contains(endpoint, "?") {
+					sep = "&"
+				}
+				endpoint += sep + encoded
+			}
+			data = nil
+		}
+	}
+
+	var body io.Reader
+	if data != nil {
+		jsonData, err := json.Marshal(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal request data: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	token, overridden := tokenFromContext(ctx)
+	if !overridden {
+		token = c.currentToken()
+	}
+	if useToken && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.LogAttrs(ctx, slog.LevelDebug, "request",
+			slog.String("method", method), slog.String("path", path),
+			slog.Duration("duration", time.Since(start)), slog.Any("error", err))
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, fmt.Errorf("request aborted: %w", ctxErr)
+		}
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Only the path and status are logged; headers and bodies carry
+	// credentials and tokens
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "request",
+		slog.String("method", method), slog.String("path", path),
+		slog.Int("status", resp.StatusCode), slog.Duration("duration", time.Since(start)))
+
+	// Handle 401 Unauthorized - token might be expired
+	if resp.StatusCode == 401 {
+		if strings.Contains(string(respBody), "invalid_client") {
+			apiErr := newAPIError(resp.StatusCode, respBody)
+			apiErr.Message = ErrInvalidCredentials
+			return nil, nil, apiErr
+		}
+
+		// A refresh can't help requests sent without a token, such as the
+		// token request itself, or with a caller-supplied token. Revoked
+		// credentials would otherwise refresh and retry forever.
+		if !useToken || overridden || authRetries >= maxAuthRetries {
+			return nil, nil, newAPIError(resp.StatusCode, respBody)
+		}
+
+		// Don't refresh and retry on behalf of a cancelled call
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		// Try to refresh token and retry request. Callers that raced on the
+		// same expired token share a single refresh.
+		if err := c.refreshToken(ctx, token); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrTokenRefreshFailed, err)
+		}
+
+		// Retry the request with new token
+		return c.doRequestAttempt(ctx, baseURL, path, method, data, true, authRetries+1)
+	}
+
+	return respBody, resp, nil
+}
+
+// encodeQuery converts map request data into query parameters. Values are
+// formatted with fmt.Sprint, so ints, strings and bools all encode as text.
+// Zero values (0, "", false and nil) are omitted, since the API treats a
+// missing filter as unset and a blank one as a filter; offset=0 is the same
+// as no offset. To send a zero value on purpose, pass it as a non-empty
+// string such as "0" or "false". It reports false if data is not a map.
+func encodeQuery(data interface{}) (url.Values, bool) {
+	query := url.Values{}
+	switch params := data.(type) {
+	case map[string]interface{}:
+		for key, value := range params {
+			if value == nil || reflect.ValueOf(value).IsZero() {
+				continue
+			}
+			query.Set(key, fmt.Sprint(value))
+		}
+	case map[string]string:
+		for key, value := range params {
+			if value != "" {
+				query.Set(key, value)
+			}
+		}
+	default:
+		return nil, false
+	}
+	return query, true
+}
+
+// decodeList parses a list response into v and returns the total number of
+// items available. The API returns either a bare array, with the total in the
+// X-Total-Count header, or an object with "data" and "total" fields. The
+// total is -1 when the response doesn't include it.
+func decodeList(body []byte, header http.Header, v interface{}) (int, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var page struct {
+			Data  json.RawMessage `json:"data"`
+			Total *int            `json:"total"`
+		}
+		if err := json.Unmarshal(trimmed, &page); err != nil {
+			return 0, err
+		}
+		if err := json.Unmarshal(page.Data, v); err != nil {
+			return 0, err
+		}
+		if page.Total == nil {
+			return -1, nil
+		}
+		return *page.Total, nil
+	}
+
+	if err := json.Unmarshal(trimmed, v); err != nil {
+		return 0, err
+	}
+
+	total, err := strconv.Atoi(header.Get("X-Total-Count"))
+	if err != nil {
+		return -1, nil
+	}
+	return total, nil
+}
+
+// Address Books
+
+// ListAddressBooks retrieves the list of address books
+func (c *Client) ListAddressBooks(limit, offset int) ([]AddressBook, error) {
+	return c.ListAddressBooksContext(context.Background(), limit, offset)
+}
+
+// ListAddressBooksContext is like ListAddressBooks but uses ctx for cancellation and deadlines
+func (c *Client) ListAddressBooksContext(ctx context.Context, limit, offset int) ([]AddressBook, error) {
+	books, _, err := c.ListAddressBooksWithTotalContext(ctx, limit, offset)
+	return books, err
+}
+
+// ListAddressBooksWithTotal retrieves a page of address books along with the
+// total number of address books, or -1 if the API didn't report it
+func (c *Client) ListAddressBooksWithTotal(limit, offset int) ([]AddressBook, int, error) {
+	return c.ListAddressBooksWithTotalContext(context.Background(), limit, offset)
+}
+
+// ListAddressBooksWithTotalContext is like ListAddressBooksWithTotal but uses ctx for cancellation and deadlines
+func (c *Client) ListAddressBooksWithTotalContext(ctx context.Context, limit, offset int) ([]AddressBook, int, error) {
+	params := make(map[string]interface{})
+	if limit > 0 {
+		params["limit"] = limit
+	}
+	if offset > 0 {
+		params["offset"] = offset
+	}
+
+	resp, header, err := c.doRequest(ctx, "addressbooks", "GET", params, true)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var books []AddressBook
+	total, err := decodeList(resp, header, &books)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse address books: %w", err)
+	}
+
+	return books, total, nil
+}
+
+// IterateAddressBooks pages through all address books pageSize at a time,
+// calling fn for each one. It stops at the first error returned by fn or by a
+// request.
+func (c *Client) IterateAddressBooks(ctx context.Context, pageSize int, fn func(AddressBook) error) error {
+	if pageSize <= 0 {
+		pageSize = campaignPageSize
+	}
+
+	for offset := 0; ; offset += pageSize {
+		books, total, err := c.ListAddressBooksWithTotalContext(ctx, pageSize, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, book := range books {
+			if err := fn(book); err != nil {
+				return err
+			}
+		}
+
+		if len(books) < pageSize || (total >= 0 && offset+len(books) >= total) {
+			return nil
+		}
+	}
+}
+
+// CreateAddressBook creates a new address book
+func (c *Client) CreateAddressBook(name string) (*AddressBook, error) {
+	return c.CreateAddressBookContext(context.Background(), name)
+}
+
+// CreateAddressBookContext is like CreateAddressBook but uses ctx for cancellation and deadlines
+func (c *Client) CreateAddressBookContext(ctx context.Context, name string) (*AddressBook, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty book name")
+	}
+
+	data := map[string]string{"bookName": name}
+	resp, err := c.sendRequest(ctx, "addressbooks", "POST", data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var book AddressBook
+	if err := json.Unmarshal(resp, &book); err != nil {
+		return nil, fmt.Errorf("failed to parse address book: %w", err)
+	}
+
+	return &book, nil
+}
+
+// EditAddressBook edits an address book name and returns the updated book
+func (c *Client) EditAddressBook(id int, name string) (*AddressBook, error) {
+	return c.EditAddressBookContext(context.Background(), id, name)
+}
+
+// EditAddressBookContext is like EditAddressBook but uses ctx for cancellation and deadlines
+func (c *Client) EditAddressBookContext(ctx context.Context, id int, name string) (*AddressBook, error) {
+	if id == 0 || name == "" {
+		return nil, fmt.Errorf("empty book name or book id")
+	}
+
+	data := map[string]string{"name": name}
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d", id), "PUT", data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	book := &AddressBook{ID: id, Name: name}
+
+	// The API usually answers with just a result flag, or nothing at all
+	if len(bytes.TrimSpace(resp)) == 0 {
+		return book, nil
+	}
+
+	var result struct {
+		Result *bool  `json:"result"`
+		ID     int    `json:"id"`
+		Name   string `json:"name"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse address book: %w", err)
+	}
+	if result.Result != nil && !*result.Result {
+		return nil, fmt.Errorf("address book %d was not updated", id)
+	}
+	if result.Name != "" {
+		book.Name = result.Name
+	}
+
+	return book, nil
+}
+
+// RemoveAddressBook removes an address book
+func (c *Client) RemoveAddressBook(id int) error {
+	return c.RemoveAddressBookContext(context.Background(), id)
+}
+
+// RemoveAddressBookContext is like RemoveAddressBook but uses ctx for cancellation and deadlines
+func (c *Client) RemoveAddressBookContext(ctx context.Context, id int) error {
+	if id == 0 {
+		return fmt.Errorf("empty book id")
+	}
+
+	_, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d", id), "DELETE", nil, true)
+	return err
+}
+
+// GetBookInfo retrieves information about an address book
+func (c *Client) GetBookInfo(id int) (*AddressBook, error) {
+	return c.GetBookInfoContext(context.Background(), id)
+}
+
+// GetBookInfoContext is like GetBookInfo but uses ctx for cancellation and deadlines
+func (c *Client) GetBookInfoContext(ctx context.Context, id int) (*AddressBook, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("empty book id")
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d", id), "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var book AddressBook
+	if err := json.Unmarshal(resp, &book); err != nil {
+		return nil, fmt.Errorf("failed to parse address book: %w", err)
+	}
+
+	return &book, nil
+}
+
+// GetBookVariables retrieves the variable definitions of an address book
+func (c *Client) GetBookVariables(id int) ([]BookVariable, error) {
+	return c.GetBookVariablesContext(context.Background(), id)
+}
+
+// GetBookVariablesContext is like GetBookVariables but uses ctx for cancellation and deadlines
+func (c *Client) GetBookVariablesContext(ctx context.Context, id int) ([]BookVariable, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("empty book id")
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d/variables", id), "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var variables []BookVariable
+	if err := json.Unmarshal(resp, &variables); err != nil {
+		return nil, fmt.Errorf("failed to parse book variables: %w", err)
+	}
+
+	return variables, nil
+}
+
+// AddBookVariable adds a variable definition to an address book
+func (c *Client) AddBookVariable(id int, variable BookVariable) error {
+	return c.AddBookVariableContext(context.Background(), id, variable)
+}
+
+// AddBookVariableContext is like AddBookVariable but uses ctx for cancellation and deadlines
+func (c *Client) AddBookVariableContext(ctx context.Context, id int, variable BookVariable) error {
+	if id == 0 || variable.Name == "" {
+		return fmt.Errorf("empty variable name or book id")
+	}
+
+	_, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d/variables", id), "POST", variable, true)
+	return err
+}
+
+// CloneBookStructure creates a new address book with the same variable
+// definitions as the source book but without any subscribers
+func (c *Client) CloneBookStructure(sourceID int, newName string) (*AddressBook, error) {
+	return c.CloneBookStructureContext(context.Background(), sourceID, newName)
+}
+
+// CloneBookStructureContext is like CloneBookStructure but uses ctx for cancellation and deadlines
+func (c *Client) CloneBookStructureContext(ctx context.Context, sourceID int, newName string) (*AddressBook, error) {
+	if sourceID == 0 || newName == "" {
+		return nil, fmt.Errorf("empty book name or source book id")
+	}
+
+	variables, err := c.GetBookVariablesContext(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := c.CreateAddressBookContext(ctx, newName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range variables {
+		if err := c.AddBookVariableContext(ctx, book.ID, v); err != nil {
+			return book, fmt.Errorf("failed to copy variable %s: %w", v.Name, err)
+		}
+	}
+
+	return book, nil
+}
+
+// GetBookGrowthStats retrieves the daily subscriber changes of an address
+// book between from and to. Days without activity are omitted.
+func (c *Client) GetBookGrowthStats(bookID int, from, to time.Time) ([]BookGrowthPoint, error) {
+	return c.GetBookGrowthStatsContext(context.Background(), bookID, from, to)
+}
+
+// GetBookGrowthStatsContext is like GetBookGrowthStats but uses ctx for cancellation and deadlines
+func (c *Client) GetBookGrowthStatsContext(ctx context.Context, bookID int, from, to time.Time) ([]BookGrowthPoint, error) {
+	if bookID == 0 {
+		return nil, fmt.Errorf("empty book id")
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid date range")
+	}
+
+	params := map[string]string{
+		"date_from": from.Format("2006-01-02"),
+		"date_to":   to.Format("2006-01-02"),
+	}
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d/stats", bookID), "GET", params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []struct {
+		Date         string `json:"date"`
+		Subscribed   int    `json:"subscribed"`
+		Unsubscribed int    `json:"unsubscribed"`
+	}
+	if err := json.Unmarshal(resp, &days); err != nil {
+		return nil, fmt.Errorf("failed to parse book stats: %w", err)
+	}
+
+	points := make([]BookGrowthPoint, 0, len(days))
+	for _, day := range days {
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse book stats date: %w", err)
+		}
+		points = append(points, BookGrowthPoint{
+			Date:    date,
+			Added:   day.Subscribed,
+			Removed: day.Unsubscribed,
+			Delta:   day.Subscribed - day.Unsubscribed,
+		})
+	}
+
+	return points, nil
+}
+
+// Email Management
+
+// GetEmailsCount retrieves the number of emails in an address book without
+// downloading them
+func (c *Client) GetEmailsCount(bookID int) (int, error) {
+	return c.GetEmailsCountContext(context.Background(), bookID)
+}
+
+// GetEmailsCountContext is like GetEmailsCount but uses ctx for cancellation and deadlines
+func (c *Client) GetEmailsCountContext(ctx context.Context, bookID int) (int, error) {
+	if bookID == 0 {
+		return 0, fmt.Errorf("empty book id")
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d/emails/total", bookID), "GET", nil, true)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		Total FlexInt `json:"total"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse email count: %w", err)
+	}
+
+	return int(result.Total), nil
+}
+
+// GetEmailsFromBook retrieves a page of email addresses from an address book
+func (c *Client) GetEmailsFromBook(id, limit, offset int) ([]Email, error) {
+	return c.GetEmailsFromBookContext(context.Background(), id, limit, offset)
+}
+
+// GetEmailsFromBookContext is like GetEmailsFromBook but uses ctx for cancellation and deadlines
+func (c *Client) GetEmailsFromBookContext(ctx context.Context, id, limit, offset int) ([]Email, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("empty book id")
+	}
+
+	params := make(map[string]interface{})
+	if limit > 0 {
+		params["limit"] = limit
+	}
+	if offset > 0 {
+		params["offset"] = offset
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d/emails", id), "GET", params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var emails []Email
+	if err := json.Unmarshal(resp, &emails); err != nil {
+		return nil, fmt.Errorf("failed to parse emails: %w", err)
+	}
+
+	return emails, nil
+}
+
+// GetAllEmailsFromBook retrieves every email address in an address book,
+// paging through it emailPageSize at a time
+func (c *Client) GetAllEmailsFromBook(id int) ([]Email, error) {
+	return c.GetAllEmailsFromBookContext(context.Background(), id)
+}
+
+// GetAllEmailsFromBookContext is like GetAllEmailsFromBook but uses ctx for cancellation and deadlines
+func (c *Client) GetAllEmailsFromBookContext(ctx context.Context, id int) ([]Email, error) {
+	var all []Email
+	for offset := 0; ; offset += emailPageSize {
+		emails, err := c.GetEmailsFromBookContext(ctx, id, emailPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, emails...)
+		if len(emails) < emailPageSize {
+			return all, nil
+		}
+	}
+}
+
+// GetEmailsByVariable retrieves the emails in an address book whose variable
+// is set to value
+func (c *Client) GetEmailsByVariable(bookID int, variable, value string) ([]Email, error) {
+	return c.GetEmailsByVariableContext(context.Background(), bookID, variable, value)
+}
+
+// GetEmailsByVariableContext is like GetEmailsByVariable but uses ctx for cancellation and deadlines
+func (c *Client) GetEmailsByVariableContext(ctx context.Context, bookID int, variable, value string) ([]Email, error) {
+	if bookID == 0 || variable == "" {
+		return nil, fmt.Errorf("empty variable name or book id")
+	}
+
+	path := fmt.Sprintf("addressbooks/%d/emails/variable/%s/%s", bookID, url.PathEscape(variable), url.PathEscape(value))
+	resp, header, err := c.doRequest(ctx, path, "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var emails []Email
+	if _, err := decodeList(resp, header, &emails); err != nil {
+		return nil, fmt.Errorf("failed to parse emails: %w", err)
+	}
+
+	return emails, nil
+}
+
+// DiscoverOption configures a DiscoverVariables call
+type DiscoverOption func(*discoverOptions)
+
+type discoverOptions struct {
+	sampleSize int
+}
+
+// WithSampleSize caps the number of contacts DiscoverVariables inspects
+func WithSampleSize(n int) DiscoverOption {
+	return func(o *discoverOptions) {
+		o.sampleSize = n
+	}
+}
+
+// DiscoverVariables collects the distinct variable names set on the contacts
+// of an address book, inspecting at most DefaultDiscoverSampleSize contacts
+// unless overridden with WithSampleSize
+func (c *Client) DiscoverVariables(bookID int, opts ...DiscoverOption) ([]string, error) {
+	return c.DiscoverVariablesContext(context.Background(), bookID, opts...)
+}
+
+// DiscoverVariablesContext is like DiscoverVariables but uses ctx for cancellation and deadlines
+func (c *Client) DiscoverVariablesContext(ctx context.Context, bookID int, opts ...DiscoverOption) ([]string, error) {
+	options := discoverOptions{sampleSize: DefaultDiscoverSampleSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	seen := make(map[string]bool)
+	var variables []string
+	for offset := 0; offset < options.sampleSize; offset += emailPageSize {
+		limit := min(emailPageSize, options.sampleSize-offset)
+		emails, err := c.GetEmailsFromBookContext(ctx, bookID, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range emails {
+			for key := range e.Variables {
+				if !seen[key] {
+					seen[key] = true
+					variables = append(variables, key)
+				}
+			}
+		}
+
+		if len(emails) < limit {
+			break
+		}
+	}
+	sort.Strings(variables)
+
+	return variables, nil
+}
+
+// GetBookForTemplating retrieves the contacts of an address book together
+// with its variable definitions
+func (c *Client) GetBookForTemplating(bookID int) (*BookExport, error) {
+	return c.GetBookForTemplatingContext(context.Background(), bookID)
+}
+
+// GetBookForTemplatingContext is like GetBookForTemplating but uses ctx for cancellation and deadlines
+func (c *Client) GetBookForTemplatingContext(ctx context.Context, bookID int) (*BookExport, error) {
+	emails, err := c.GetAllEmailsFromBookContext(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := c.GetBookVariablesContext(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BookExport{BookID: bookID, Emails: emails, Variables: variables}, nil
+}
+
+// GetUnsubscribedEmails retrieves the unsubscribed email addresses of an address book
+func (c *Client) GetUnsubscribedEmails(id int) ([]string, error) {
+	return c.GetUnsubscribedEmailsContext(context.Background(), id)
+}
+
+// GetUnsubscribedEmailsContext is like GetUnsubscribedEmails but uses ctx for cancellation and deadlines
+func (c *Client) GetUnsubscribedEmailsContext(ctx context.Context, id int) ([]string, error) {
+	emails, err := c.GetAllEmailsFromBookContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var unsubscribed []string
+	for _, e := range emails {
+		if e.Status == EmailStatusUnsubscribed {
+			unsubscribed = append(unsubscribed, e.Email)
+		}
+	}
+
+	return unsubscribed, nil
+}
+
+// PurgeUnsubscribed removes all unsubscribed email addresses from an address
+// book and returns the number of addresses removed
+func (c *Client) PurgeUnsubscribed(bookID int) (int, error) {
+	return c.PurgeUnsubscribedContext(context.Background(), bookID)
+}
+
+// PurgeUnsubscribedContext is like PurgeUnsubscribed but uses ctx for cancellation and deadlines
+func (c *Client) PurgeUnsubscribedContext(ctx context.Context, bookID int) (int, error) {
+	emails, err := c.GetUnsubscribedEmailsContext(ctx, bookID)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(emails) == 0 {
+		return 0, nil
+	}
+
+	return c.RemoveEmailsContext(ctx, bookID, emails)
+}
+
+// AddEmailsOption configures an AddEmails call
+type AddEmailsOption func(*addEmailsOptions)
+
+type addEmailsOptions struct {
+	validate  bool
+	chunkSize int
+}
+
+// WithValidation asks the API to validate addresses on import and report the
+// ones it rejects
+func WithValidation(validate bool) AddEmailsOption {
+	return func(o *addEmailsOptions) {
+		o.validate = validate
+	}
+}
+
+// WithChunkSize sets how many emails AddEmails sends per request instead of
+// AddEmailsChunkSize
+func WithChunkSize(size int) AddEmailsOption {
+	return func(o *addEmailsOptions) {
+		o.chunkSize = size
+	}
+}
+
+// AddEmailsResult represents the outcome of adding emails to an address book
+type AddEmailsResult struct {
+	// Added counts the emails accepted by the API
+	Added int
+
+	// Rejected lists the addresses refused by validation
+	Rejected []string
+
+	// Unsent holds the emails of chunks whose request failed, so they can
+	// be retried
+	Unsent []Email
+}
+
+// AddEmails adds new emails to an address book in chunks, continuing past
+// failed chunks. It returns the partial result along with any chunk errors.
+func (c *Client) AddEmails(bookID int, emails []Email, opts ...AddEmailsOption) (*AddEmailsResult, error) {
+	return c.AddEmailsContext(context.Background(), bookID, emails, opts...)
+}
+
+// AddEmailsContext is like AddEmails but uses ctx for cancellation and deadlines
+func (c *Client) AddEmailsContext(ctx context.Context, bookID int, emails []Email, opts ...AddEmailsOption) (*AddEmailsResult, error) {
+	if bookID == 0 || len(emails) == 0 {
+		return nil, fmt.Errorf("empty email list or book id")
+	}
+
+	var options addEmailsOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	chunkSize := options.chunkSize
+	if chunkSize <= 0 {
+		chunkSize = AddEmailsChunkSize
+	}
+
+	result := &AddEmailsResult{}
+	var errs []error
+	for start := 0; start < len(emails); start += chunkSize {
+		end := min(start+chunkSize, len(emails))
+		chunk := emails[start:end]
+
+		rejected, err := c.addEmailsChunk(ctx, bookID, chunk, options.validate)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to add emails %d-%d: %w", start, end-1, err))
+			result.Unsent = append(result.Unsent, chunk...)
+			continue
+		}
+		result.Added += len(chunk) - len(rejected)
+		result.Rejected = append(result.Rejected, rejected...)
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// addEmailsChunk adds one chunk of emails and returns the addresses rejected
+// by validation
+func (c *Client) addEmailsChunk(ctx context.Context, bookID int, emails []Email, validate bool) ([]string, error) {
+	emailsJSON, err := json.Marshal(emails)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize emails: %w", err)
+	}
+
+	data := map[string]interface{}{"emails": string(emailsJSON)}
+	if validate {
+		data["validate"] = 1
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d/emails", bookID), "POST", data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !validate {
+		return nil, nil
+	}
+
+	var raw struct {
+		Rejected []string `json:"rejected_emails"`
+	}
+	if err := json.Unmarshal(resp, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse add emails result: %w", err)
+	}
+
+	return raw.Rejected, nil
+}
+
+// RemoveEmails removes email addresses from an address book in chunks of
+// RemoveEmailsChunkSize, continuing past failed chunks. It returns the number
+// of addresses removed along with any chunk errors.
+func (c *Client) RemoveEmails(bookID int, emails []string) (int, error) {
+	return c.RemoveEmailsContext(context.Background(), bookID, emails)
+}
+
+// RemoveEmailsContext is like RemoveEmails but uses ctx for cancellation and deadlines
+func (c *Client) RemoveEmailsContext(ctx context.Context, bookID int, emails []string) (int, error) {
+	if bookID == 0 || len(emails) == 0 {
+		return 0, fmt.Errorf("empty email list or book id")
+	}
+
+	removed := 0
+	var errs []error
+	for start := 0; start < len(emails); start += RemoveEmailsChunkSize {
+		end := min(start+RemoveEmailsChunkSize, len(emails))
+		chunk := emails[start:end]
+
+		emailsJSON, err := json.Marshal(chunk)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to serialize emails %d-%d: %w", start, end-1, err))
+			continue
+		}
+
+		data := map[string]string{"emails": string(emailsJSON)}
+		if _, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d/emails", bookID), "DELETE", data, true); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove emails %d-%d: %w", start, end-1, err))
+			continue
+		}
+		removed += len(chunk)
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+// UnsubscribeEmails marks email addresses in an address book as
+// unsubscribed, keeping them and their history in the book
+func (c *Client) UnsubscribeEmails(bookID int, emails []string) error {
+	return c.UnsubscribeEmailsContext(context.Background(), bookID, emails)
+}
+
+// UnsubscribeEmailsContext is like UnsubscribeEmails but uses ctx for cancellation and deadlines
+func (c *Client) UnsubscribeEmailsContext(ctx context.Context, bookID int, emails []string) error {
+	return c.updateSubscription(ctx, bookID, emails, "POST")
+}
+
+// ActivateEmails resubscribes email addresses previously unsubscribed from an
+// address book
+func (c *Client) ActivateEmails(bookID int, emails []string) error {
+	return c.ActivateEmailsContext(context.Background(), bookID, emails)
+}
+
+// ActivateEmailsContext is like ActivateEmails but uses ctx for cancellation and deadlines
+func (c *Client) ActivateEmailsContext(ctx context.Context, bookID int, emails []string) error {
+	return c.updateSubscription(ctx, bookID, emails, "DELETE")
+}
+
+// updateSubscription sends emails to the book's unsubscribe endpoint, which
+// unsubscribes them with POST and reactivates them with DELETE
+func (c *Client) updateSubscription(ctx context.Context, bookID int, emails []string, method string) error {
+	if bookID == 0 || len(emails) == 0 {
+		return fmt.Errorf("empty email list or book id")
+	}
+
+	emailsJSON, err := json.Marshal(emails)
+	if err != nil {
+		return fmt.Errorf("failed to serialize emails: %w", err)
+	}
+
+	data := map[string]string{"emails": string(emailsJSON)}
+	_, err = c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d/emails/unsubscribe", bookID), method, data, true)
+	return err
+}
+
+// GetEmailInfo retrieves information about an email address from an address book
+func (c *Client) GetEmailInfo(bookID int, email string) (*Email, error) {
+	return c.GetEmailInfoContext(context.Background(), bookID, email)
+}
+
+// GetEmailInfoContext is like GetEmailInfo but uses ctx for cancellation and deadlines
+func (c *Client) GetEmailInfoContext(ctx context.Context, bookID int, email string) (*Email, error) {
+	if bookID == 0 || email == "" {
+		return nil, fmt.Errorf("empty email or book id")
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d/emails/%s", bookID, email), "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var emailInfo Email
+	if err := json.Unmarshal(resp, &emailInfo); err != nil {
+		return nil, fmt.Errorf("failed to parse email info: %w", err)
+	}
+
+	return &emailInfo, nil
+}
+
+// GetEmailGlobalInfo retrieves the address books an email address belongs to
+// along with its variables in each
+func (c *Client) GetEmailGlobalInfo(email string) ([]EmailBookInfo, error) {
+	return c.GetEmailGlobalInfoContext(context.Background(), email)
+}
+
+// GetEmailGlobalInfoContext is like GetEmailGlobalInfo but uses ctx for cancellation and deadlines
+func (c *Client) GetEmailGlobalInfoContext(ctx context.Context, email string) ([]EmailBookInfo, error) {
+	if email == "" {
+		return nil, fmt.Errorf("empty email")
+	}
+
+	resp, err := c.sendRequest(ctx, "emails/"+url.PathEscape(email), "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var info []EmailBookInfo
+	if err := json.Unmarshal(resp, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse email info: %w", err)
+	}
+
+	return info, nil
+}
+
+// GetEmailCampaigns retrieves how many campaigns were sent to an email
+// address and how many it opened and clicked, along with whether it is
+// blacklisted, when it was last active and the address books it belongs to
+func (c *Client) GetEmailCampaigns(email string) (*EmailCampaignStats, error) {
+	return c.GetEmailCampaignsContext(context.Background(), email)
+}
+
+// GetEmailCampaignsContext is like GetEmailCampaigns but uses ctx for cancellation and deadlines
+func (c *Client) GetEmailCampaignsContext(ctx context.Context, email string) (*EmailCampaignStats, error) {
+	if email == "" {
+		return nil, fmt.Errorf("empty email")
+	}
+
+	resp, err := c.sendRequest(ctx, "emails/"+url.PathEscape(email)+"/campaigns", "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		Statistic struct {
+			Sent FlexInt `json:"sent"`
+			Open FlexInt `json:"open"`
+			Link FlexInt `json:"link"`
+		} `json:"statistic"`
+		Blacklist    FlexBool `json:"blacklist"`
+		LastActivity FlexTime `json:"last_activity"`
+	}
+	if err := json.Unmarshal(resp, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse email campaigns: %w", err)
+	}
+
+	// The campaign statistics don't include the books or their statuses
+	books, err := c.GetEmailGlobalInfoContext(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address books: %w", err)
+	}
+
+	return &EmailCampaignStats{
+		Sent:         int(raw.Statistic.Sent),
+		Opened:       int(raw.Statistic.Open),
+		Clicked:      int(raw.Statistic.Link),
+		Blacklisted:  bool(raw.Blacklist),
+		LastActivity: raw.LastActivity.Time,
+		Books:        books,
+	}, nil
+}
+
+// GetEmailBooks retrieves every address book containing an email address
+func (c *Client) GetEmailBooks(email string) ([]AddressBook, error) {
+	return c.GetEmailBooksContext(context.Background(), email)
+}
+
+// GetEmailBooksContext is like GetEmailBooks but uses ctx for cancellation and deadlines
+func (c *Client) GetEmailBooksContext(ctx context.Context, email string) ([]AddressBook, error) {
+	info, err := c.GetEmailGlobalInfoContext(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	books := make([]AddressBook, 0, len(info))
+	for _, entry := range info {
+		book, err := c.GetBookInfoContext(ctx, entry.BookID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get book %d: %w", entry.BookID, err)
+		}
+		books = append(books, *book)
+	}
+
+	return books, nil
+}
+
+// GetMergedContact merges an email address's variables across all address
+// books it belongs to, with later books overriding earlier ones
+func (c *Client) GetMergedContact(email string) (map[string]interface{}, error) {
+	return c.GetMergedContactContext(context.Background(), email)
+}
+
+// GetMergedContactContext is like GetMergedContact but uses ctx for cancellation and deadlines
+func (c *Client) GetMergedContactContext(ctx context.Context, email string) (map[string]interface{}, error) {
+	return c.GetMergedContactWithStrategyContext(ctx, email, MergeLastWins)
+}
+
+// GetMergedContactWithStrategy merges an email address's variables across all
+// address books it belongs to, resolving conflicts with strategy
+func (c *Client) GetMergedContactWithStrategy(email string, strategy MergeStrategy) (map[string]interface{}, error) {
+	return c.GetMergedContactWithStrategyContext(context.Background(), email, strategy)
+}
+
+// GetMergedContactWithStrategyContext is like GetMergedContactWithStrategy but uses ctx for cancellation and deadlines
+func (c *Client) GetMergedContactWithStrategyContext(ctx context.Context, email string, strategy MergeStrategy) (map[string]interface{}, error) {
+	info, err := c.GetEmailGlobalInfoContext(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]interface{})
+	for _, book := range info {
+		for key, value := range book.Variables {
+			if _, exists := merged[key]; exists && strategy == MergeFirstWins {
+				continue
+			}
+			merged[key] = value
+		}
+	}
+
+	return merged, nil
+}
+
+// UpdateEmailVariables updates variables for an email address in an address book
+func (c *Client) UpdateEmailVariables(bookID int, email string, variables map[string]interface{}) error {
+	return c.UpdateEmailVariablesContext(context.Background(), bookID, email, variables)
+}
+
+// UpdateEmailVariablesContext is like UpdateEmailVariables but uses ctx for cancellation and deadlines
+func (c *Client) UpdateEmailVariablesContext(ctx context.Context, bookID int, email string, variables map[string]interface{}) error {
+	if bookID == 0 || email == "" || len(variables) == 0 {
+		return fmt.Errorf("empty email, variables or book id")
+	}
+
+	data := map[string]interface{}{
+		"email":     email,
+		"variables": variables,
+	}
+
+	_, err := c.sendRequest(ctx, fmt.Sprintf("addressbooks/%d/emails/variable", bookID), "POST", data, true)
+	return err
+}
+
+// Campaigns
+
+// ListCampaigns retrieves the list of campaigns
+func (c *Client) ListCampaigns(limit, offset int) ([]Campaign, error) {
+	return c.ListCampaignsContext(context.Background(), limit, offset)
+}
+
+// ListCampaignsContext is like ListCampaigns but uses ctx for cancellation and deadlines
+func (c *Client) ListCampaignsContext(ctx context.Context, limit, offset int) ([]Campaign, error) {
+	campaigns, _, err := c.ListCampaignsWithTotalContext(ctx, limit, offset)
+	return campaigns, err
+}
+
+// ListCampaignsWithTotal retrieves a page of campaigns along with the total
+// number of campaigns, or -1 if the API didn't report it
+func (c *Client) ListCampaignsWithTotal(limit, offset int) ([]Campaign, int, error) {
+	return c.ListCampaignsWithTotalContext(context.Background(), limit, offset)
+}
+
+// ListCampaignsWithTotalContext is like ListCampaignsWithTotal but uses ctx for cancellation and deadlines
+func (c *Client) ListCampaignsWithTotalContext(ctx context.Context, limit, offset int) ([]Campaign, int, error) {
+	params := make(map[string]interface{})
+	if limit > 0 {
+		params["limit"] = limit
+	}
+	if offset > 0 {
+		params["offset"] = offset
+	}
+
+	resp, header, err := c.doRequest(ctx, "campaigns", "GET", params, true)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var campaigns []Campaign
+	total, err := decodeList(resp, header, &campaigns)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse campaigns: %w", err)
+	}
+
+	return campaigns, total, nil
+}
+
+// ListCampaignSummaries retrieves a page of campaign summaries along with the
+// total number of campaigns, or -1 if the API didn't report it
+func (c *Client) ListCampaignSummaries(limit, offset int) ([]CampaignSummary, int, error) {
+	return c.ListCampaignSummariesContext(context.Background(), limit, offset)
+}
+
+// ListCampaignSummariesContext is like ListCampaignSummaries but uses ctx for cancellation and deadlines
+func (c *Client) ListCampaignSummariesContext(ctx context.Context, limit, offset int) ([]CampaignSummary, int, error) {
+	campaigns, total, err := c.ListCampaignsWithTotalContext(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	summaries := make([]CampaignSummary, len(campaigns))
+	for i, campaign := range campaigns {
+		summaries[i] = CampaignSummary{
+			ID:        campaign.ID,
+			Name:      campaign.Name,
+			Status:    campaign.Status,
+			SentCount: campaign.AllEmailQty,
+		}
+	}
+
+	return summaries, total, nil
+}
+
+// IterateCampaigns pages through all campaigns pageSize at a time, calling fn
+// for each one. It stops at the first error returned by fn or by a request.
+func (c *Client) IterateCampaigns(ctx context.Context, pageSize int, fn func(Campaign) error) error {
+	if pageSize <= 0 {
+		pageSize = campaignPageSize
+	}
+
+	for offset := 0; ; offset += pageSize {
+		campaigns, total, err := c.ListCampaignsWithTotalContext(ctx, pageSize, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, campaign := range campaigns {
+			if err := fn(campaign); err != nil {
+				return err
+			}
+		}
+
+		if len(campaigns) < pageSize || (total >= 0 && offset+len(campaigns) >= total) {
+			return nil
+		}
+	}
+}
+
+// ExportCampaignsCSV writes the metadata of every campaign to w as CSV
+func (c *Client) ExportCampaignsCSV(w io.Writer) error {
+	return c.ExportCampaignsCSVContext(context.Background(), w)
+}
+
+// ExportCampaignsCSVContext is like ExportCampaignsCSV but uses ctx for cancellation and deadlines
+func (c *Client) ExportCampaignsCSVContext(ctx context.Context, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "name", "status", "sender_name", "sender_email", "subject", "sent"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	err := c.IterateCampaigns(ctx, campaignPageSize, func(campaign Campaign) error {
+		return cw.Write([]string{
+			strconv.Itoa(campaign.ID),
+			campaign.Name,
+			campaign.Status,
+			campaign.SenderName,
+			campaign.SenderEmail,
+			campaign.Subject,
+			strconv.Itoa(campaign.AllEmailQty),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// GetCampaignInfo retrieves information about a campaign
+func (c *Client) GetCampaignInfo(id int) (*Campaign, error) {
+	return c.GetCampaignInfoContext(context.Background(), id)
+}
+
+// GetCampaignInfoContext is like GetCampaignInfo but uses ctx for cancellation and deadlines
+func (c *Client) GetCampaignInfoContext(ctx context.Context, id int) (*Campaign, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("empty campaign id")
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("campaigns/%d", id), "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var campaign Campaign
+	if err := json.Unmarshal(resp, &campaign); err != nil {
+		return nil, fmt.Errorf("failed to parse campaign: %w", err)
+	}
+
+	return &campaign, nil
+}
+
+// GetCampaignVariables retrieves the merge fields referenced by a campaign's
+// body, sorted and without duplicates. The body is scanned exactly as the API
+// returns it.
+func (c *Client) GetCampaignVariables(id int) ([]string, error) {
+	return c.GetCampaignVariablesContext(context.Background(), id)
+}
+
+// GetCampaignVariablesContext is like GetCampaignVariables but uses ctx for cancellation and deadlines
+func (c *Client) GetCampaignVariablesContext(ctx context.Context, id int) ([]string, error) {
+	campaign, err := c.GetCampaignInfoContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if campaign.Message == nil {
+		return nil, fmt.Errorf("campaign %d has no message", id)
+	}
+
+	seen := make(map[string]bool)
+	var variables []string
+	for _, match := range mergeFieldPattern.FindAllStringSubmatch(campaign.Message.Body, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			variables = append(variables, match[1])
+		}
+	}
+	sort.Strings(variables)
+
+	return variables, nil
+}
+
+// GetCampaignSchedule retrieves the time a scheduled campaign will be sent,
+// interpreted in the client's Timezone
+func (c *Client) GetCampaignSchedule(id int) (time.Time, error) {
+	return c.GetCampaignScheduleContext(context.Background(), id)
+}
+
+// GetCampaignScheduleContext is like GetCampaignSchedule but uses ctx for cancellation and deadlines
+func (c *Client) GetCampaignScheduleContext(ctx context.Context, id int) (time.Time, error) {
+	campaign, err := c.GetCampaignInfoContext(ctx, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if campaign.SendDate == "" {
+		return time.Time{}, fmt.Errorf("campaign %d is not scheduled", id)
+	}
+
+	loc := c.Timezone
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	sendDate, err := time.ParseInLocation("2006-01-02 15:04:05", campaign.SendDate, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse campaign send date: %w", err)
+	}
+
+	return sendDate, nil
+}
+
+// GetGroupStatistics adds up the statistics of every campaign in a group
+func (c *Client) GetGroupStatistics(groupID int) (*GroupStats, error) {
+	return c.GetGroupStatisticsContext(context.Background(), groupID)
+}
+
+// GetGroupStatisticsContext is like GetGroupStatistics but uses ctx for cancellation and deadlines
+func (c *Client) GetGroupStatisticsContext(ctx context.Context, groupID int) (*GroupStats, error) {
+	if groupID == 0 {
+		return nil, fmt.Errorf("empty group id")
+	}
+
+	var ids []int
+	err := c.IterateCampaigns(ctx, campaignPageSize, func(campaign Campaign) error {
+		if campaign.GroupID == groupID {
+			ids = append(ids, campaign.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The campaign list doesn't include statistics, so fetch each campaign
+	stats := &GroupStats{GroupID: groupID, Statistics: make(map[string]int)}
+	for _, id := range ids {
+		campaign, err := c.GetCampaignInfoContext(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get campaign %d: %w", id, err)
+		}
+
+		stats.Campaigns++
+		stats.Sent += campaign.AllEmailQty
+		for _, stat := range campaign.Statistics {
+			stats.Statistics[stat.Explain] += stat.Count
+		}
+	}
+
+	return stats, nil
+}
+
+// GetCampaignRecipients retrieves the per-recipient status of a sent
+// campaign, paging through all recipients. statusFilter restricts the result
+// to one of the Recipient* statuses; empty returns every recipient.
+func (c *Client) GetCampaignRecipients(id int, statusFilter string) ([]RecipientStatus, error) {
+	return c.GetCampaignRecipientsContext(context.Background(), id, statusFilter)
+}
+
+// GetCampaignRecipientsContext is like GetCampaignRecipients but uses ctx for cancellation and deadlines
+func (c *Client) GetCampaignRecipientsContext(ctx context.Context, id int, statusFilter string) ([]RecipientStatus, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("empty campaign id")
+	}
+	switch statusFilter {
+	case "", RecipientDelivered, RecipientOpened, RecipientClicked, RecipientBounced:
+	default:
+		return nil, fmt.Errorf("invalid recipient status %q", statusFilter)
+	}
+
+	var recipients []RecipientStatus
+	for offset := 0; ; offset += emailPageSize {
+		params := map[string]interface{}{
+			"limit":  emailPageSize,
+			"offset": offset,
+			"status": statusFilter,
+		}
+		body, header, err := c.doRequest(ctx, fmt.Sprintf("campaigns/%d/recipients", id), "GET", params, true)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []RecipientStatus
+		total, err := decodeList(body, header, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse campaign recipients: %w", err)
+		}
+		recipients = append(recipients, page...)
+
+		if len(page) < emailPageSize || (total >= 0 && len(recipients) >= total) {
+			return recipients, nil
+		}
+	}
+}
+
+// CreateCampaign creates a new email campaign
+func (c *Client) CreateCampaign(senderName, senderEmail, subject, body string, bookID int, name string, attachments []string) (*Campaign, error) {
+	return c.CreateCampaignContext(context.Background(), senderName, senderEmail, subject, body, bookID, name, attachments)
+}
+
+// CreateCampaignContext is like CreateCampaign but uses ctx for cancellation and deadlines
+func (c *Client) CreateCampaignContext(ctx context.Context, senderName, senderEmail, subject, body string, bookID int, name string, attachments []string) (*Campaign, error) {
+	if c.paused.Load() {
+		return nil, ErrClientPaused
+	}
+
+	data, err := campaignData(senderName, senderEmail, subject, body, bookID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(attachments) > 0 {
+		sizes := make([]int, len(attachments))
+		for i, a := range attachments {
+			sizes[i] = len(a)
+		}
+		if err := c.checkAttachmentSize(sizes...); err != nil {
+			return nil, err
+		}
+
+		attachmentsJSON, _ := json.Marshal(attachments)
+		data["attachments"] = string(attachmentsJSON)
+	}
+
+	return c.createCampaign(ctx, data)
+}
+
+// CreateCampaignWithAttachments creates a new email campaign with file
+// attachments, such as those returned by AttachmentFromFile
+func (c *Client) CreateCampaignWithAttachments(senderName, senderEmail, subject, body string, bookID int, name string, attachments []Attachment) (*Campaign, error) {
+	return c.CreateCampaignWithAttachmentsContext(context.Background(), senderName, senderEmail, subject, body, bookID, name, attachments)
+}
+
+// CreateCampaignWithAttachmentsContext is like CreateCampaignWithAttachments but uses ctx for cancellation and deadlines
+func (c *Client) CreateCampaignWithAttachmentsContext(ctx context.Context, senderName, senderEmail, subject, body string, bookID int, name string, attachments []Attachment) (*Campaign, error) {
+	if c.paused.Load() {
+		return nil, ErrClientPaused
+	}
+
+	data, err := campaignData(senderName, senderEmail, subject, body, bookID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(attachments) > 0 {
+		sizes := make([]int, len(attachments))
+		binary := make(map[string]string, len(attachments))
+		for i, a := range attachments {
+			if a.Filename == "" {
+				return nil, fmt.Errorf("attachment without filename")
+			}
+			sizes[i] = len(a.Content)
+			binary[a.Filename] = base64.StdEncoding.EncodeToString(a.Content)
+		}
+		if err := c.checkAttachmentSize(sizes...); err != nil {
+			return nil, err
+		}
+		data["attachments_binary"] = binary
+	}
+
+	return c.createCampaign(ctx, data)
+}
+
+// campaignData validates the required campaign fields and returns the
+// request data for creating the campaign
+func campaignData(senderName, senderEmail, subject, body string, bookID int, name string) (map[string]interface{}, error) {
+	if senderName == "" || senderEmail == "" || subject == "" || body == "" || bookID == 0 {
+		return nil, fmt.Errorf("missing required campaign data")
+	}
+
+	return map[string]interface{}{
+		"sender_name":  senderName,
+		"sender_email": senderEmail,
+		"subject":      subject,
+		"body":         base64.StdEncoding.EncodeToString([]byte(body)),
+		"list_id":      bookID,
+		"name":         name,
+	}, nil
+}
+
+// createCampaign submits campaign data and parses the created campaign
+func (c *Client) createCampaign(ctx context.Context, data map[string]interface{}) (*Campaign, error) {
+	resp, err := c.sendRequest(ctx, "campaigns", "POST", data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var campaign Campaign
+	if err := json.Unmarshal(resp, &campaign); err != nil {
+		return nil, fmt.Errorf("failed to parse campaign: %w", err)
+	}
+
+	return &campaign, nil
+}
+
+// CancelCampaign cancels a campaign
+func (c *Client) CancelCampaign(id int) error {
+	return c.CancelCampaignContext(context.Background(), id)
+}
+
+// CancelCampaignContext is like CancelCampaign but uses ctx for cancellation and deadlines
+func (c *Client) CancelCampaignContext(ctx context.Context, id int) error {
+	if id == 0 {
+		return fmt.Errorf("empty campaign id")
+	}
+
+	_, err := c.sendRequest(ctx, fmt.Sprintf("campaigns/%d", id), "DELETE", nil, true)
+	return err
+}
+
+// checkAttachmentSize verifies that attachments of the given raw sizes stay
+// within MaxAttachmentSize once base64-encoded
+func (c *Client) checkAttachmentSize(sizes ...int) error {
+	if c.MaxAttachmentSize <= 0 {
+		return nil
+	}
+
+	var total int64
+	for _, size := range sizes {
+		total += int64(base64.StdEncoding.EncodedLen(size))
+	}
+
+	if total > c.MaxAttachmentSize {
+		return fmt.Errorf("%w: %d bytes encoded, limit is %d", ErrAttachmentsTooLarge, total, c.MaxAttachmentSize)
+	}
+
+	return nil
+}
+
+// SendCampaignTest sends a campaign to test recipients ahead of the real send
+func (c *Client) SendCampaignTest(id int, testEmails []string) error {
+	return c.SendCampaignTestContext(context.Background(), id, testEmails)
+}
+
+// SendCampaignTestContext is like SendCampaignTest but uses ctx for cancellation and deadlines
+func (c *Client) SendCampaignTestContext(ctx context.Context, id int, testEmails []string) error {
+	if c.paused.Load() {
+		return ErrClientPaused
+	}
+
+	if id == 0 || len(testEmails) == 0 {
+		return fmt.Errorf("empty test email list or campaign id")
+	}
+
+	for _, email := range testEmails {
+		if !strings.Contains(strings.TrimSpace(email), "@") {
+			return fmt.Errorf("invalid test email %q", email)
+		}
+	}
+
+	data := map[string]interface{}{"emails": testEmails}
+	_, err := c.sendRequest(ctx, fmt.Sprintf("campaigns/%d/test", id), "POST", data, true)
+	return err
+}
+
+// DeleteCampaigns deletes multiple campaigns concurrently and returns the
+// result of each deletion keyed by campaign id. The returned error is non-nil
+// if any deletion failed.
+func (c *Client) DeleteCampaigns(ids []int) (map[int]error, error) {
+	return c.DeleteCampaignsContext(context.Background(), ids)
+}
+
+// DeleteCampaignsContext is like DeleteCampaigns but uses ctx for cancellation and deadlines
+func (c *Client) DeleteCampaignsContext(ctx context.Context, ids []int) (map[int]error, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("empty campaign id list")
+	}
+
+	results := make(map[int]error, len(ids))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, deleteConcurrency)
+
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			err := c.CancelCampaignContext(ctx, id)
+
+			mu.Lock()
+			results[id] = err
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range results {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d campaign deletions failed", failed, len(results))
+	}
+
+	return results, nil
+}
+
+// SMTP Functions
+
+// SMTPSendMail sends an email via SMTP and returns the ID of the sent message
+//
+// Deprecated: Use SMTPSend, which takes a typed SMTPEmail.
+func (c *Client) SMTPSendMail(emailData map[string]interface{}) (*SMTPSendResult, error) {
+	return c.SMTPSendMailContext(context.Background(), emailData)
+}
+
+// SMTPSendMailContext is like SMTPSendMail but uses ctx for cancellation and deadlines
+//
+// Deprecated: Use SMTPSendContext, which takes a typed SMTPEmail.
+func (c *Client) SMTPSendMailContext(ctx context.Context, emailData map[string]interface{}) (*SMTPSendResult, error) {
+	if c.paused.Load() {
+		return nil, ErrClientPaused
+	}
+
+	if emailData == nil {
+		return nil, fmt.Errorf("empty email data")
+	}
+
+	if sender := c.sender(); sender != nil {
+		if _, ok := emailData["from"]; !ok {
+			emailData["from"] = *sender
+		}
+	}
+
+	// Encode HTML content if present
+	if html, ok := emailData["html"].(string); ok {
+		emailData["html"] = base64.StdEncoding.EncodeToString([]byte(html))
+	}
+
+	emailJSON, err := json.Marshal(emailData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize email data: %w", err)
+	}
+
+	data := map[string]string{"email": string(emailJSON)}
+	resp, err := c.sendRequest(ctx, "smtp/emails", "POST", data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result SMTPSendResult
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse send result: %w", err)
+	}
+
+	return &result, nil
+}
+
+// SetDefaultSender sets the sender used by SMTP sends that don't specify one
+func (c *Client) SetDefaultSender(sender Contact) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defaultSender = &sender
+}
+
+// sender returns the default sender, or nil if none is set
+func (c *Client) sender() *Contact {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.defaultSender
+}
+
+// SMTPSend sends a typed email via SMTP
+func (c *Client) SMTPSend(email SMTPEmail) (*SMTPSendResult, error) {
+	return c.SMTPSendContext(context.Background(), email)
+}
+
+// SMTPSendContext is like SMTPSend but uses ctx for cancellation and deadlines
+func (c *Client) SMTPSendContext(ctx context.Context, email SMTPEmail) (*SMTPSendResult, error) {
+	if c.paused.Load() {
+		return nil, ErrClientPaused
+	}
+
+	if len(email.To) == 0 {
+		return nil, fmt.Errorf("empty recipient list")
+	}
+
+	if sender := c.sender(); email.From.Email == "" && sender != nil {
+		email.From = *sender
+	}
+
+	if email.Charset == "" {
+		email.Charset = DefaultCharset
+	}
+	if email.ContentType == "" {
+		email.ContentType = DefaultContentType
+	}
+
+	// Encode HTML content if present
+	if email.HTML != "" {
+		email.HTML = base64.StdEncoding.EncodeToString([]byte(email.HTML))
+	}
+
+	payload := smtpEmailPayload{SMTPEmail: email}
+	if email.TemplateID != 0 {
+		payload.Template = &templatePayload{ID: email.TemplateID, Variables: email.TemplateVariables}
+	}
+	sizes := make([]int, 0, len(email.Attachments))
+	for _, a := range email.Attachments {
+		if a.Filename == "" {
+			return nil, fmt.Errorf("attachment without filename")
+		}
+		sizes = append(sizes, len(a.Content))
+
+		content := base64.StdEncoding.EncodeToString(a.Content)
+		if a.ContentID != "" {
+			payload.InlineAttachments = append(payload.InlineAttachments, inlineAttachmentPayload{
+				ContentID:   a.ContentID,
+				Filename:    a.Filename,
+				ContentType: a.ContentType,
+				Content:     content,
+			})
+			continue
+		}
+
+		if payload.AttachmentsBinary == nil {
+			payload.AttachmentsBinary = make(map[string]string)
+		}
+		payload.AttachmentsBinary[a.Filename] = content
+	}
+	if err := c.checkAttachmentSize(sizes...); err != nil {
+		return nil, err
+	}
+
+	emailJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize email data: %w", err)
+	}
+
+	data := map[string]string{"email": string(emailJSON)}
+	resp, err := c.sendRequest(ctx, "smtp/emails", "POST", data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result SMTPSendResult
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse send result: %w", err)
+	}
+
+	return &result, nil
+}
+
+// SendMessage validates and sends a typed message via SMTP. It requires a
+// subject and at least one recipient, and every recipient must have an
+// email address.
+func (c *Client) SendMessage(msg SMTPMessage) (*SMTPSendResult, error) {
+	return c.SendMessageContext(context.Background(), msg)
+}
+
+// SendMessageContext is like SendMessage but uses ctx for cancellation and deadlines
+func (c *Client) SendMessageContext(ctx context.Context, msg SMTPMessage) (*SMTPSendResult, error) {
+	if len(msg.To) == 0 {
+		return nil, fmt.Errorf("empty recipient list")
+	}
+	if strings.TrimSpace(msg.Subject) == "" {
+		return nil, fmt.Errorf("empty subject")
+	}
+	for _, list := range [][]Contact{msg.To, msg.CC, msg.BCC} {
+		for _, recipient := range list {
+			if strings.TrimSpace(recipient.Email) == "" {
+				return nil, fmt.Errorf("recipient without email address")
+			}
+		}
+	}
+
+	return c.SMTPSendContext(ctx, msg)
+}
+
+// SMTPSendMailWithAttachments sends email via SMTP with the files at the
+// given paths attached under their base names. File sizes are checked against
+// MaxAttachmentSize before any file is read.
+func (c *Client) SMTPSendMailWithAttachments(email SMTPEmail, files []string) (*SMTPSendResult, error) {
+	return c.SMTPSendMailWithAttachmentsContext(context.Background(), email, files)
+}
+
+// SMTPSendMailWithAttachmentsContext is like SMTPSendMailWithAttachments but uses ctx for cancellation and deadlines
+func (c *Client) SMTPSendMailWithAttachmentsContext(ctx context.Context, email SMTPEmail, files []string) (*SMTPSendResult, error) {
+	attachments, err := c.readAttachments(files)
+	if err != nil {
+		return nil, err
+	}
+
+	email.Attachments = append(append([]Attachment(nil), email.Attachments...), attachments...)
+	return c.SMTPSendContext(ctx, email)
+}
+
+// readAttachments reads the files at paths as attachments named after their
+// base names, rejecting them up front if they are too large together
+func (c *Client) readAttachments(paths []string) ([]Attachment, error) {
+	sizes := make([]int, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment: %w", err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("attachment %s is a directory", path)
+		}
+		sizes[i] = int(info.Size())
+
+		name := filepath.Base(path)
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate attachment filename %s", name)
+		}
+		seen[name] = true
+	}
+	if err := c.checkAttachmentSize(sizes...); err != nil {
+		return nil, err
+	}
+
+	attachments := make([]Attachment, len(paths))
+	for i, path := range paths {
+		attachment, err := AttachmentFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		attachments[i] = attachment
+	}
+
+	return attachments, nil
+}
+
+// AttachmentFromFile reads the file at path into an Attachment named after
+// its base name. The content type is inferred from the extension, falling
+// back to sniffing the content.
+func AttachmentFromFile(path string) (Attachment, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to read attachment: %w", err)
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+
+	return Attachment{
+		Filename:    filepath.Base(path),
+		ContentType: contentType,
+		Content:     content,
+	}, nil
+}
+
+// SendSeedTest sends a copy of an email to each seed address to check inbox
+// placement. Seeds are deduplicated and one result is returned per seed.
+func (c *Client) SendSeedTest(email SMTPEmail, seeds []string) ([]SMTPSendResult, error) {
+	return c.SendSeedTestContext(context.Background(), email, seeds)
+}
+
+// SendSeedTestContext is like SendSeedTest but uses ctx for cancellation and deadlines
+func (c *Client) SendSeedTestContext(ctx context.Context, email SMTPEmail, seeds []string) ([]SMTPSendResult, error) {
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("empty seed list")
+	}
+
+	seen := make(map[string]bool)
+	var results []SMTPSendResult
+	var errs []error
+	for _, seed := range seeds {
+		key := NormalizeEmail(seed, NormalizeOptions{})
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		email.To = []Contact{{Email: strings.TrimSpace(seed)}}
+		result, err := c.SMTPSendContext(ctx, email)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to send to seed %s: %w", seed, err))
+			result = &SMTPSendResult{}
+		}
+		result.Recipient = email.To[0].Email
+		results = append(results, *result)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// Transactional email delivery statuses accepted by WithEmailStatus
+const (
+	SMTPStatusDelivered = "delivered"
+	SMTPStatusOpened    = "opened"
+	SMTPStatusBounced   = "bounced"
+	SMTPStatusSpam      = "spam"
+)
+
+// SMTPListOption configures an SMTPListEmails call
+type SMTPListOption func(*smtpListOptions)
+
+type smtpListOptions struct {
+	status string
+}
+
+// WithEmailStatus restricts SMTPListEmails to emails with one of the
+// SMTPStatus* delivery statuses
+func WithEmailStatus(status string) SMTPListOption {
+	return func(o *smtpListOptions) {
+		o.status = status
+	}
+}
+
+// SMTPListEmails retrieves list of sent emails
+func (c *Client) SMTPListEmails(limit, offset int, fromDate, toDate, sender, recipient string, opts ...SMTPListOption) ([]map[string]interface{}, error) {
+	return c.SMTPListEmailsContext(context.Background(), limit, offset, fromDate, toDate, sender, recipient, opts...)
+}
+
+// SMTPListEmailsContext is like SMTPListEmails but uses ctx for cancellation and deadlines
+func (c *Client) SMTPListEmailsContext(ctx context.Context, limit, offset int, fromDate, toDate, sender, recipient string, opts ...SMTPListOption) ([]map[string]interface{}, error) {
+	var options smtpListOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	switch options.status {
+	case "", SMTPStatusDelivered, SMTPStatusOpened, SMTPStatusBounced, SMTPStatusSpam:
+	default:
+		return nil, fmt.Errorf("invalid email status %q", options.status)
+	}
+
+	params := map[string]interface{}{
+		"limit":     limit,
+		"offset":    offset,
+		"from":      fromDate,
+		"to":        toDate,
+		"sender":    sender,
+		"recipient": recipient,
+		"status":    options.status,
+	}
+
+	resp, err := c.sendRequest(ctx, "smtp/emails", "GET", params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var emails []map[string]interface{}
+	if err := json.Unmarshal(resp, &emails); err != nil {
+		return nil, fmt.Errorf("failed to parse emails: %w", err)
+	}
+
+	return emails, nil
+}
+
+// IterateSMTPEmails pages through all sent emails pageSize at a time, calling
+// fn for each one. opts filter the emails as for SMTPListEmails. It stops at
+// the first error returned by fn or by a request.
+func (c *Client) IterateSMTPEmails(ctx context.Context, pageSize int, fn func(map[string]interface{}) error, opts ...SMTPListOption) error {
+	if pageSize <= 0 {
+		pageSize = emailPageSize
+	}
+
+	for offset := 0; ; offset += pageSize {
+		emails, err := c.SMTPListEmailsContext(ctx, pageSize, offset, "", "", "", "", opts...)
+		if err != nil {
+			return err
+		}
+
+		for _, email := range emails {
+			if err := fn(email); err != nil {
+				return err
+			}
+		}
+
+		if len(emails) < pageSize {
+			return nil
+		}
+	}
+}
+
+// SMTPGetBounceReason retrieves the SMTP-level reason a sent email bounced,
+// such as "550 5.1.1: User unknown". It returns an empty reason if the
+// email was not bounced.
+func (c *Client) SMTPGetBounceReason(emailID string) (string, error) {
+	return c.SMTPGetBounceReasonContext(context.Background(), emailID)
+}
+
+// SMTPGetBounceReasonContext is like SMTPGetBounceReason but uses ctx for cancellation and deadlines
+func (c *Client) SMTPGetBounceReasonContext(ctx context.Context, emailID string) (string, error) {
+	if emailID == "" {
+		return "", fmt.Errorf("empty email id")
+	}
+
+	resp, err := c.sendRequest(ctx, "smtp/emails/"+url.PathEscape(emailID), "GET", nil, true)
+	if err != nil {
+		return "", err
+	}
+
+	var info struct {
+		AnswerCode        FlexInt `json:"smtp_answer_code"`
+		AnswerSubcode     string  `json:"smtp_answer_subcode"`
+		AnswerCodeExplain string  `json:"smtp_answer_code_explain"`
+		AnswerData        string  `json:"smtp_answer_data"`
+	}
+	if err := json.Unmarshal(resp, &info); err != nil {
+		return "", fmt.Errorf("failed to parse email info: %w", err)
+	}
+
+	// Permanent and temporary failures use 4xx and 5xx SMTP reply codes
+	if info.AnswerCode < 400 {
+		return "", nil
+	}
+
+	reason := strconv.Itoa(int(info.AnswerCode))
+	if info.AnswerSubcode != "" {
+		reason += " " + info.AnswerSubcode
+	}
+	detail := info.AnswerData
+	if detail == "" {
+		detail = info.AnswerCodeExplain
+	}
+	if detail != "" {
+		reason += ": " + strings.TrimSpace(detail)
+	}
+
+	return reason, nil
+}
+
+// SMS Functions
+
+// SMSAddPhones adds phone numbers to an address book in chunks of
+// AddPhonesChunkSize and reports how many were added and why any were
+// rejected. It continues past failed chunks, returning the partial result
+// along with any chunk errors.
+func (c *Client) SMSAddPhones(bookID int, phones []string) (*AddPhonesResult, error) {
+	return c.SMSAddPhonesContext(context.Background(), bookID, phones)
+}
+
+// SMSAddPhonesContext is like SMSAddPhones but uses ctx for cancellation and deadlines
+func (c *Client) SMSAddPhonesContext(ctx context.Context, bookID int, phones []string) (*AddPhonesResult, error) {
+	if bookID == 0 || len(phones) == 0 {
+		return nil, fmt.Errorf("empty phones or book id")
+	}
+
+	result := &AddPhonesResult{}
+	var errs []error
+	for start := 0; start < len(phones); start += AddPhonesChunkSize {
+		end := min(start+AddPhonesChunkSize, len(phones))
+		chunk := phones[start:end]
+
+		if err := c.addPhonesChunk(ctx, bookID, chunk, result); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add phones %d-%d: %w", start, end-1, err))
+			result.Unsent = append(result.Unsent, chunk...)
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// addPhonesChunk adds one chunk of phones, accumulating the outcome in result
+func (c *Client) addPhonesChunk(ctx context.Context, bookID int, phones []string, result *AddPhonesResult) error {
+	phonesJSON, err := json.Marshal(phones)
+	if err != nil {
+		return fmt.Errorf("failed to serialize phones: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"addressBookId": bookID,
+		"phones":        string(phonesJSON),
+	}
+
+	resp, err := c.sendRequest(ctx, "sms/numbers", "POST", data, true)
+	if err != nil {
+		return err
+	}
+
+	var raw struct {
+		Counters struct {
+			Added      int `json:"added"`
+			Exceptions int `json:"exceptions"`
+		} `json:"counters"`
+		Exceptions map[string]string `json:"exceptions"`
+	}
+	if err := json.Unmarshal(resp, &raw); err != nil {
+		return fmt.Errorf("failed to parse add phones result: %w", err)
+	}
+
+	result.Added += raw.Counters.Added
+	result.Failed += raw.Counters.Exceptions
+	for phone, reason := range raw.Exceptions {
+		if result.Failures == nil {
+			result.Failures = make(map[string]string)
+		}
+		result.Failures[phone] = reason
+	}
+
+	return nil
+}
+
+// SMSAddPhonesWithVariables adds phone numbers with variables to an address book
+func (c *Client) SMSAddPhonesWithVariables(bookID int, phones []Phone) error {
+	return c.SMSAddPhonesWithVariablesContext(context.Background(), bookID, phones)
+}
+
+// SMSAddPhonesWithVariablesContext is like SMSAddPhonesWithVariables but uses ctx for cancellation and deadlines
+func (c *Client) SMSAddPhonesWithVariablesContext(ctx context.Context, bookID int, phones []Phone) error {
+	if bookID == 0 || len(phones) == 0 {
+		return fmt.Errorf("empty phones or book id")
+	}
+
+	phonesJSON, err := json.Marshal(phones)
+	if err != nil {
+		return fmt.Errorf("failed to serialize phones: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"addressBookId": bookID,
+		"phones":        string(phonesJSON),
+	}
+
+	_, err = c.sendRequest(ctx, "sms/numbers/variables", "POST", data, true)
+	return err
+}
+
+// SMSRemovePhones removes phone numbers from an address book
+func (c *Client) SMSRemovePhones(bookID int, phones []string) error {
+	return c.SMSRemovePhonesContext(context.Background(), bookID, phones)
+}
+
+// SMSRemovePhonesContext is like SMSRemovePhones but uses ctx for cancellation and deadlines
+func (c *Client) SMSRemovePhonesContext(ctx context.Context, bookID int, phones []string) error {
+	if bookID == 0 || len(phones) == 0 {
+		return fmt.Errorf("empty phones or book id")
+	}
+
+	phonesJSON, err := json.Marshal(phones)
+	if err != nil {
+		return fmt.Errorf("failed to serialize phones: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"addressBookId": bookID,
+		"phones":        string(phonesJSON),
+	}
+
+	_, err = c.sendRequest(ctx, "sms/numbers", "DELETE", data, true)
+	return err
+}
+
+// SMSGetPhoneInfo retrieves a phone number in an address book along with its
+// variables
+func (c *Client) SMSGetPhoneInfo(bookID int, phone string) (*Phone, error) {
+	return c.SMSGetPhoneInfoContext(context.Background(), bookID, phone)
+}
+
+// SMSGetPhoneInfoContext is like SMSGetPhoneInfo but uses ctx for cancellation and deadlines
+func (c *Client) SMSGetPhoneInfoContext(ctx context.Context, bookID int, phone string) (*Phone, error) {
+	if bookID == 0 || phone == "" {
+		return nil, fmt.Errorf("empty phone or book id")
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("sms/numbers/info/%d/%s", bookID, url.PathEscape(phone)), "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data Phone `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse phone info: %w", err)
+	}
+
+	return &result.Data, nil
+}
+
+// SMSSend sends SMS to specified phone numbers
+func (c *Client) SMSSend(senderName string, phones []string, body string, date *time.Time, transliterate bool, route string) error {
+	return c.SMSSendContext(context.Background(), senderName, phones, body, date, transliterate, route)
+}
+
+// SMSSendContext is like SMSSend but uses ctx for cancellation and deadlines
+func (c *Client) SMSSendContext(ctx context.Context, senderName string, phones []string, body string, date *time.Time, transliterate bool, route string) error {
+	_, err := c.SMSSendWithResultContext(ctx, senderName, phones, body, date, transliterate, route)
+	return err
+}
+
+// SMSSendWithResult sends SMS to specified phone numbers and returns the
+// campaign id and cost reported by the API
+func (c *Client) SMSSendWithResult(senderName string, phones []string, body string, date *time.Time, transliterate bool, route string) (*SMSSendResult, error) {
+	return c.SMSSendWithResultContext(context.Background(), senderName, phones, body, date, transliterate, route)
+}
+
+// SMSSendWithResultContext is like SMSSendWithResult but uses ctx for cancellation and deadlines
+func (c *Client) SMSSendWithResultContext(ctx context.Context, senderName string, phones []string, body string, date *time.Time, transliterate bool, route string) (*SMSSendResult, error) {
+	if c.paused.Load() {
+		return nil, ErrClientPaused
+	}
+
+	if senderName == "" || len(phones) == 0 || body == "" {
+		return nil, fmt.Errorf("missing required SMS data")
+	}
+
+	if c.SkipBlacklistedPhones {
+		blacklisted, err := c.smsBlacklisted(ctx, phones)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check SMS blacklist: %w", err)
+		}
+
+		var allowed []string
+		for _, phone := range phones {
+			if !blacklisted[normalizePhone(phone)] {
+				allowed = append(allowed, phone)
+			}
+		}
+		if len(allowed) == 0 {
+			return nil, fmt.Errorf("all phones are blacklisted")
+		}
+		phones = allowed
+	}
+
+	phonesJSON, err := json.Marshal(phones)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize phones: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"sender":        senderName,
+		"phones":        string(phonesJSON),
+		"body":          body,
+		"transliterate": transliterate,
+		"route":         route,
+	}
+
+	if date != nil {
+		data["date"] = date.Format("2006-01-02 15:04:05")
+	}
+
+	resp, err := c.sendRequest(ctx, "sms/send", "POST", data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result SMSSendResult
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse SMS send result: %w", err)
+	}
+
+	return &result, nil
+}
+
+// SMSGetMessageStatus retrieves the delivery status of a single SMS
+func (c *Client) SMSGetMessageStatus(messageID string) (*SMSStatus, error) {
+	return c.SMSGetMessageStatusContext(context.Background(), messageID)
+}
+
+// SMSGetMessageStatusContext is like SMSGetMessageStatus but uses ctx for cancellation and deadlines
+func (c *Client) SMSGetMessageStatusContext(ctx context.Context, messageID string) (*SMSStatus, error) {
+	if messageID == "" {
+		return nil, fmt.Errorf("empty message id")
+	}
+
+	resp, err := c.sendRequest(ctx, "sms/messages/"+url.PathEscape(messageID), "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		Data struct {
+			ID      string `json:"id"`
+			Phone   string `json:"phone"`
+			Status  string `json:"status"`
+			Updated string `json:"updated"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse SMS status: %w", err)
+	}
+
+	status := &SMSStatus{
+		MessageID: raw.Data.ID,
+		Phone:     raw.Data.Phone,
+		Status:    raw.Data.Status,
+	}
+	if raw.Data.Updated != "" {
+		status.UpdatedAt, err = time.Parse("2006-01-02 15:04:05", raw.Data.Updated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SMS status time: %w", err)
+		}
 	}
 
-	var emails []Email
-	if err := json.Unmarshal(resp, &emails); err != nil {
-		return nil, fmt.Errorf("failed to parse emails: %w", err)
-	}
+	return status, nil
+}
 
-	return emails, nil
+// SMSIsBlacklisted reports whether a phone number is in the SMS blacklist
+func (c *Client) SMSIsBlacklisted(phone string) (bool, error) {
+	return c.SMSIsBlacklistedContext(context.Background(), phone)
 }
 
-// AddEmails adds new emails to an address book
-func (c *Client) AddEmails(bookID int, emails []Email) error {
-	if bookID == 0 || len(emails) == 0 {
-		return fmt.Errorf("empty email list or book id")
+// SMSIsBlacklistedContext is like SMSIsBlacklisted but uses ctx for cancellation and deadlines
+func (c *Client) SMSIsBlacklistedContext(ctx context.Context, phone string) (bool, error) {
+	if normalizePhone(phone) == "" {
+		return false, fmt.Errorf("empty phone")
 	}
 
-	emailsJSON, err := json.Marshal(emails)
+	blacklisted, err := c.smsBlacklisted(ctx, []string{phone})
 	if err != nil {
-		return fmt.Errorf("failed to serialize emails: %w", err)
+		return false, err
 	}
 
-	data := map[string]string{"emails": string(emailsJSON)}
-	_, err = c.sendRequest(fmt.Sprintf("addressbooks/%d/emails", bookID), "POST", data, true)
-	return err
+	return blacklisted[normalizePhone(phone)], nil
 }
 
-// RemoveEmails removes email addresses from an address book
-func (c *Client) RemoveEmails(bookID int, emails []string) error {
-	if bookID == 0 || len(emails) == 0 {
-		return fmt.Errorf("empty email list or book id")
+// smsBlacklisted returns the set of normalized numbers from phones that are
+// in the SMS blacklist
+func (c *Client) smsBlacklisted(ctx context.Context, phones []string) (map[string]bool, error) {
+	normalized := make([]string, 0, len(phones))
+	for _, phone := range phones {
+		if n := normalizePhone(phone); n != "" {
+			normalized = append(normalized, n)
+		}
 	}
 
-	emailsJSON, err := json.Marshal(emails)
+	phonesJSON, err := json.Marshal(normalized)
 	if err != nil {
-		return fmt.Errorf("failed to serialize emails: %w", err)
-	}
-
-	data := map[string]string{"emails": string(emailsJSON)}
-	_, err = c.sendRequest(fmt.Sprintf("addressbooks/%d/emails", bookID), "DELETE", data, true)
-	return err
-}
-
-// GetEmailInfo retrieves information about an email address from an address book
-func (c *Client) GetEmailInfo(bookID int, email string) (*Email, error) {
-	if bookID == 0 || email == "" {
-		return nil, fmt.Errorf("empty email or book id")
+		return nil, fmt.Errorf("failed to serialize phones: %w", err)
 	}
 
-	resp, err := c.sendRequest(fmt.Sprintf("addressbooks/%d/emails/%s", bookID, email), "GET", nil, true)
+	params := map[string]string{"phones": string(phonesJSON)}
+	resp, err := c.sendRequest(ctx, "sms/black_list/by_numbers", "GET", params, true)
 	if err != nil {
 		return nil, err
 	}
 
-	var emailInfo Email
-	if err := json.Unmarshal(resp, &emailInfo); err != nil {
-		return nil, fmt.Errorf("failed to parse email info: %w", err)
+	var result struct {
+		Data []struct {
+			Phone string `json:"phone"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse SMS blacklist: %w", err)
 	}
 
-	return &emailInfo, nil
-}
-
-// UpdateEmailVariables updates variables for an email address in an address book
-func (c *Client) UpdateEmailVariables(bookID int, email string, variables map[string]interface{}) error {
-	if bookID == 0 || email == "" || len(variables) == 0 {
-		return fmt.Errorf("empty email, variables or book id")
+	blacklisted := make(map[string]bool, len(result.Data))
+	for _, entry := range result.Data {
+		blacklisted[normalizePhone(entry.Phone)] = true
 	}
 
-	data := map[string]interface{}{
-		"email":     email,
-		"variables": variables,
+	return blacklisted, nil
+}
+
+// normalizePhone strips everything but digits from a phone number
+func normalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
 	}
+	return b.String()
+}
 
-	_, err := c.sendRequest(fmt.Sprintf("addressbooks/%d/emails/variable", bookID), "POST", data, true)
-	return err
+// SMSAddCampaign creates a new SMS campaign
+func (c *Client) SMSAddCampaign(senderName string, bookID int, body string, date *time.Time, transliterate bool) (*SMSCampaign, error) {
+	return c.SMSAddCampaignContext(context.Background(), senderName, bookID, body, date, transliterate)
 }
 
-// Campaigns
+// SMSAddCampaignContext is like SMSAddCampaign but uses ctx for cancellation and deadlines
+func (c *Client) SMSAddCampaignContext(ctx context.Context, senderName string, bookID int, body string, date *time.Time, transliterate bool) (*SMSCampaign, error) {
+	if c.paused.Load() {
+		return nil, ErrClientPaused
+	}
 
-// ListCampaigns retrieves the list of campaigns
-func (c *Client) ListCampaigns(limit, offset int) ([]Campaign, error) {
-	params := make(map[string]interface{})
-	if limit > 0 {
-		params["limit"] = limit
+	if senderName == "" || bookID == 0 || body == "" {
+		return nil, fmt.Errorf("missing required SMS campaign data")
 	}
-	if offset > 0 {
-		params["offset"] = offset
+
+	data := map[string]interface{}{
+		"sender":        senderName,
+		"addressBookId": bookID,
+		"body":          body,
+		"transliterate": transliterate,
+	}
+
+	if date != nil {
+		data["date"] = date.Format("2006-01-02 15:04:05")
 	}
 
-	resp, err := c.sendRequest("campaigns", "GET", params, true)
+	resp, err := c.sendRequest(ctx, "sms/campaigns", "POST", data, true)
 	if err != nil {
 		return nil, err
 	}
 
-	var campaigns []Campaign
-	if err := json.Unmarshal(resp, &campaigns); err != nil {
-		return nil, fmt.Errorf("failed to parse campaigns: %w", err)
+	var campaign SMSCampaign
+	if err := json.Unmarshal(resp, &campaign); err != nil {
+		return nil, fmt.Errorf("failed to parse SMS campaign: %w", err)
 	}
 
-	return campaigns, nil
+	return &campaign, nil
 }
 
-// GetCampaignInfo retrieves information about a campaign
-func (c *Client) GetCampaignInfo(id int) (*Campaign, error) {
-	if id == 0 {
-		return nil, fmt.Errorf("empty campaign id")
+// ListSMSCampaigns retrieves the SMS campaigns created between dateFrom and dateTo
+func (c *Client) ListSMSCampaigns(dateFrom, dateTo time.Time) ([]SMSCampaign, error) {
+	return c.ListSMSCampaignsContext(context.Background(), dateFrom, dateTo)
+}
+
+// ListSMSCampaignsContext is like ListSMSCampaigns but uses ctx for cancellation and deadlines
+func (c *Client) ListSMSCampaignsContext(ctx context.Context, dateFrom, dateTo time.Time) ([]SMSCampaign, error) {
+	if dateTo.Before(dateFrom) {
+		return nil, fmt.Errorf("invalid date range")
 	}
 
-	resp, err := c.sendRequest(fmt.Sprintf("campaigns/%d", id), "GET", nil, true)
+	params := map[string]string{
+		"dateFrom": dateFrom.Format("2006-01-02 15:04:05"),
+		"dateTo":   dateTo.Format("2006-01-02 15:04:05"),
+	}
+	resp, header, err := c.doRequest(ctx, "sms/campaigns/list", "GET", params, true)
 	if err != nil {
 		return nil, err
 	}
 
-	var campaign Campaign
-	if err := json.Unmarshal(resp, &campaign); err != nil {
-		return nil, fmt.Errorf("failed to parse campaign: %w", err)
+	var campaigns []SMSCampaign
+	if _, err := decodeList(resp, header, &campaigns); err != nil {
+		return nil, fmt.Errorf("failed to parse SMS campaigns: %w", err)
 	}
 
-	return &campaign, nil
+	return campaigns, nil
 }
 
-// CreateCampaign creates a new email campaign
-func (c *Client) CreateCampaign(senderName, senderEmail, subject, body string, bookID int, name string, attachments []string) (*Campaign, error) {
-	if senderName == "" || senderEmail == "" || subject == "" || body == "" || bookID == 0 {
-		return nil, fmt.Errorf("missing required campaign data")
-	}
-
-	data := map[string]interface{}{
-		"sender_name":  senderName,
-		"sender_email": senderEmail,
-		"subject":      subject,
-		"body":         base64.StdEncoding.EncodeToString([]byte(body)),
-		"list_id":      bookID,
-		"name":         name,
-	}
+// GetSMSCampaignInfo retrieves information about an SMS campaign
+func (c *Client) GetSMSCampaignInfo(id int) (*SMSCampaign, error) {
+	return c.GetSMSCampaignInfoContext(context.Background(), id)
+}
 
-	if len(attachments) > 0 {
-		attachmentsJSON, _ := json.Marshal(attachments)
-		data["attachments"] = string(attachmentsJSON)
+// GetSMSCampaignInfoContext is like GetSMSCampaignInfo but uses ctx for cancellation and deadlines
+func (c *Client) GetSMSCampaignInfoContext(ctx context.Context, id int) (*SMSCampaign, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("empty campaign id")
 	}
 
-	resp, err := c.sendRequest("campaigns", "POST", data, true)
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("sms/campaigns/info/%d", id), "GET", nil, true)
 	if err != nil {
 		return nil, err
 	}
 
-	var campaign Campaign
-	if err := json.Unmarshal(resp, &campaign); err != nil {
-		return nil, fmt.Errorf("failed to parse campaign: %w", err)
+	var result struct {
+		Data SMSCampaign `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse SMS campaign: %w", err)
 	}
 
-	return &campaign, nil
+	return &result.Data, nil
 }
 
-// CancelCampaign cancels a campaign
-func (c *Client) CancelCampaign(id int) error {
+// CancelSMSCampaign cancels a scheduled SMS campaign before it is sent
+func (c *Client) CancelSMSCampaign(id int) error {
+	return c.CancelSMSCampaignContext(context.Background(), id)
+}
+
+// CancelSMSCampaignContext is like CancelSMSCampaign but uses ctx for cancellation and deadlines
+func (c *Client) CancelSMSCampaignContext(ctx context.Context, id int) error {
 	if id == 0 {
 		return fmt.Errorf("empty campaign id")
 	}
 
-	_, err := c.sendRequest(fmt.Sprintf("campaigns/%d", id), "DELETE", nil, true)
+	_, err := c.sendRequest(ctx, fmt.Sprintf("sms/campaigns/cancel/%d", id), "PUT", nil, true)
 	return err
 }
 
-// SMTP Functions
-
-// SMTPSendMail sends an email via SMTP
-func (c *Client) SMTPSendMail(emailData map[string]interface{}) error {
-	if emailData == nil {
-		return fmt.Errorf("empty email data")
-	}
-
-	// Encode HTML content if present
-	if html, ok := emailData["html"].(string); ok {
-		emailData["html"] = base64.StdEncoding.EncodeToString([]byte(html))
-	}
+// DeleteSMSCampaign deletes an SMS campaign
+func (c *Client) DeleteSMSCampaign(id int) error {
+	return c.DeleteSMSCampaignContext(context.Background(), id)
+}
 
-	emailJSON, err := json.Marshal(emailData)
-	if err != nil {
-		return fmt.Errorf("failed to serialize email data: %w", err)
+// DeleteSMSCampaignContext is like DeleteSMSCampaign but uses ctx for cancellation and deadlines
+func (c *Client) DeleteSMSCampaignContext(ctx context.Context, id int) error {
+	if id == 0 {
+		return fmt.Errorf("empty campaign id")
 	}
 
-	data := map[string]string{"email": string(emailJSON)}
-	s, err := c.sendRequest("smtp/emails", "POST", data, true)
-	fmt.Printf("Response: %s\n", string(s))
+	data := map[string]int{"id": id}
+	_, err := c.sendRequest(ctx, "sms/campaigns", "DELETE", data, true)
 	return err
 }
 
-// SMTPListEmails retrieves list of sent emails
-func (c *Client) SMTPListEmails(limit, offset int, fromDate, toDate, sender, recipient string) ([]map[string]interface{}, error) {
-	params := map[string]interface{}{
-		"limit":     limit,
-		"offset":    offset,
-		"from":      fromDate,
-		"to":        toDate,
-		"sender":    sender,
-		"recipient": recipient,
-	}
+// GetSMSCampaignStats counts the phones of an SMS campaign by delivery status
+func (c *Client) GetSMSCampaignStats(campaignID int) (*SMSStats, error) {
+	return c.GetSMSCampaignStatsContext(context.Background(), campaignID)
+}
 
-	resp, err := c.sendRequest("smtp/emails", "GET", params, true)
+// GetSMSCampaignStatsContext is like GetSMSCampaignStats but uses ctx for cancellation and deadlines
+func (c *Client) GetSMSCampaignStatsContext(ctx context.Context, campaignID int) (*SMSStats, error) {
+	phones, err := c.smsCampaignPhones(ctx, campaignID)
 	if err != nil {
 		return nil, err
 	}
 
-	var emails []map[string]interface{}
-	if err := json.Unmarshal(resp, &emails); err != nil {
-		return nil, fmt.Errorf("failed to parse emails: %w", err)
+	stats := &SMSStats{CampaignID: campaignID, Total: len(phones)}
+	for _, phone := range phones {
+		switch phone.Status {
+		case SMSStatusDelivered:
+			stats.Delivered++
+		case SMSStatusFailed:
+			stats.Failed++
+		default:
+			stats.Pending++
+		}
 	}
 
-	return emails, nil
+	return stats, nil
 }
 
-// SMS Functions
+// GetSMSPhoneInfo retrieves the delivery status of one phone number in an
+// SMS campaign
+func (c *Client) GetSMSPhoneInfo(campaignID int, phone string) (*SMSStatus, error) {
+	return c.GetSMSPhoneInfoContext(context.Background(), campaignID, phone)
+}
 
-// SMSAddPhones adds phone numbers to an address book
-func (c *Client) SMSAddPhones(bookID int, phones []string) error {
-	if bookID == 0 || len(phones) == 0 {
-		return fmt.Errorf("empty phones or book id")
+// GetSMSPhoneInfoContext is like GetSMSPhoneInfo but uses ctx for cancellation and deadlines
+func (c *Client) GetSMSPhoneInfoContext(ctx context.Context, campaignID int, phone string) (*SMSStatus, error) {
+	if phone == "" {
+		return nil, fmt.Errorf("empty phone")
 	}
 
-	phonesJSON, err := json.Marshal(phones)
+	phones, err := c.smsCampaignPhones(ctx, campaignID)
 	if err != nil {
-		return fmt.Errorf("failed to serialize phones: %w", err)
+		return nil, err
 	}
 
-	data := map[string]interface{}{
-		"addressBookId": bookID,
-		"phones":        string(phonesJSON),
+	want := normalizePhone(phone)
+	for _, info := range phones {
+		if normalizePhone(info.Phone) == want {
+			return &SMSStatus{
+				Phone:     info.Phone,
+				Status:    info.Status,
+				UpdatedAt: info.Updated.Time,
+			}, nil
+		}
 	}
 
-	_, err = c.sendRequest("sms/numbers", "POST", data, true)
-	return err
+	return nil, fmt.Errorf("phone %s not found in campaign %d", phone, campaignID)
 }
 
-// SMSAddPhonesWithVariables adds phone numbers with variables to an address book
-func (c *Client) SMSAddPhonesWithVariables(bookID int, phones []Phone) error {
-	if bookID == 0 || len(phones) == 0 {
-		return fmt.Errorf("empty phones or book id")
-	}
-
-	phonesJSON, err := json.Marshal(phones)
-	if err != nil {
-		return fmt.Errorf("failed to serialize phones: %w", err)
-	}
-
-	data := map[string]interface{}{
-		"addressBookId": bookID,
-		"phones":        string(phonesJSON),
-	}
-
-	_, err = c.sendRequest("sms/numbers/variables", "POST", data, true)
-	return err
+// smsCampaignPhone is the delivery information of one phone in an SMS campaign
+type smsCampaignPhone struct {
+	Phone   string   `json:"phone"`
+	Status  string   `json:"status"`
+	Updated FlexTime `json:"updated"`
 }
 
-// SMSSend sends SMS to specified phone numbers
-func (c *Client) SMSSend(senderName string, phones []string, body string, date *time.Time, transliterate bool, route string) error {
-	if senderName == "" || len(phones) == 0 || body == "" {
-		return fmt.Errorf("missing required SMS data")
+// smsCampaignPhones retrieves the per-phone delivery information of an SMS campaign
+func (c *Client) smsCampaignPhones(ctx context.Context, campaignID int) ([]smsCampaignPhone, error) {
+	if campaignID == 0 {
+		return nil, fmt.Errorf("empty campaign id")
 	}
 
-	phonesJSON, err := json.Marshal(phones)
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("sms/campaigns/info/%d", campaignID), "GET", nil, true)
 	if err != nil {
-		return fmt.Errorf("failed to serialize phones: %w", err)
+		return nil, err
 	}
 
-	data := map[string]interface{}{
-		"sender":        senderName,
-		"phones":        string(phonesJSON),
-		"body":          body,
-		"transliterate": transliterate,
-		"route":         route,
+	var result struct {
+		Data struct {
+			Phones []smsCampaignPhone `json:"task_phones_info"`
+		} `json:"data"`
 	}
-
-	if date != nil {
-		data["date"] = date.Format("2006-01-02 15:04:05")
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse SMS campaign phones: %w", err)
 	}
 
-	_, err = c.sendRequest("sms/send", "POST", data, true)
-	return err
+	return result.Data.Phones, nil
 }
 
-// SMSAddCampaign creates a new SMS campaign
-func (c *Client) SMSAddCampaign(senderName string, bookID int, body string, date *time.Time, transliterate bool) (*SMSCampaign, error) {
-	if senderName == "" || bookID == 0 || body == "" {
-		return nil, fmt.Errorf("missing required SMS campaign data")
-	}
+// Utility Functions
 
-	data := map[string]interface{}{
-		"sender":        senderName,
-		"addressBookId": bookID,
-		"body":          body,
-		"transliterate": transliterate,
-	}
+// NormalizeOptions controls the optional rules applied by NormalizeEmail
+type NormalizeOptions struct {
+	// StripSubaddress removes a "+tag" suffix from the local part
+	StripSubaddress bool
+	// StripGmailDots removes dots from the local part of Gmail addresses
+	StripGmailDots bool
+}
 
-	if date != nil {
-		data["date"] = date.Format("2006-01-02 15:04:05")
+// NormalizeEmail returns a stable identity for an email address suitable for
+// deduplication. The address is always trimmed and lowercased.
+func NormalizeEmail(email string, opts NormalizeOptions) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
 	}
+	local, domain := email[:at], email[at+1:]
 
-	resp, err := c.sendRequest("sms/campaigns", "POST", data, true)
-	if err != nil {
-		return nil, err
+	if opts.StripSubaddress {
+		if plus := strings.Index(local, "+"); plus >= 0 {
+			local = local[:plus]
+		}
 	}
 
-	var campaign SMSCampaign
-	if err := json.Unmarshal(resp, &campaign); err != nil {
-		return nil, fmt.Errorf("failed to parse SMS campaign: %w", err)
+	if opts.StripGmailDots && (domain == "gmail.com" || domain == "googlemail.com") {
+		local = strings.ReplaceAll(local, ".", "")
 	}
 
-	return &campaign, nil
+	return local + "@" + domain
 }
 
-// Utility Functions
-
 // GetBalance retrieves account balance
 func (c *Client) GetBalance(currency string) (map[string]interface{}, error) {
+	return c.GetBalanceContext(context.Background(), currency)
+}
+
+// GetBalanceContext is like GetBalance but uses ctx for cancellation and deadlines
+func (c *Client) GetBalanceContext(ctx context.Context, currency string) (map[string]interface{}, error) {
 	url := "balance"
 	if currency != "" {
 		url = fmt.Sprintf("balance/%s", strings.ToUpper(currency))
 	}
 
-	resp, err := c.sendRequest(url, "GET", nil, true)
+	resp, err := c.sendRequest(ctx, url, "GET", nil, true)
 	if err != nil {
 		return nil, err
 	}
@@ -629,8 +3583,68 @@ func (c *Client) GetBalance(currency string) (map[string]interface{}, error) {
 	return balance, nil
 }
 
-// SendRawRequest sends a raw request to the API
+// BalanceTransaction represents a debit or credit on the account balance
+type BalanceTransaction struct {
+	Amount      float64
+	Currency    string
+	Type        string
+	Description string
+	Time        time.Time
+}
+
+// GetBalanceHistory retrieves the account's balance transactions between from and to
+func (c *Client) GetBalanceHistory(from, to time.Time) ([]BalanceTransaction, error) {
+	return c.GetBalanceHistoryContext(context.Background(), from, to)
+}
+
+// GetBalanceHistoryContext is like GetBalanceHistory but uses ctx for cancellation and deadlines
+func (c *Client) GetBalanceHistoryContext(ctx context.Context, from, to time.Time) ([]BalanceTransaction, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid date range")
+	}
+
+	params := map[string]string{
+		"from": from.Format("2006-01-02"),
+		"to":   to.Format("2006-01-02"),
+	}
+	resp, err := c.sendRequest(ctx, "balance/history", "GET", params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []struct {
+		Amount      float64  `json:"amount"`
+		Currency    string   `json:"currency"`
+		Type        string   `json:"type"`
+		Description string   `json:"description"`
+		Date        FlexTime `json:"date"`
+	}
+	if err := json.Unmarshal(resp, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse balance history: %w", err)
+	}
+
+	transactions := make([]BalanceTransaction, len(raw))
+	for i, t := range raw {
+		transactions[i] = BalanceTransaction{
+			Amount:      t.Amount,
+			Currency:    t.Currency,
+			Type:        t.Type,
+			Description: t.Description,
+			Time:        t.Date.Time,
+		}
+	}
+
+	return transactions, nil
+}
+
+// SendRawRequest sends a raw request to the API. While the client is paused
+// only GET requests are sent.
 func (c *Client) SendRawRequest(path, method string, data interface{}) ([]byte, error) {
+	return c.SendRawRequestContext(context.Background(), path, method, data)
+}
+
+// SendRawRequestContext is like SendRawRequest but uses ctx for cancellation and deadlines
+func (c *Client) SendRawRequestContext(ctx context.Context, path, method string, data interface{}) ([]byte, error) {
 	allowedMethods := []string{"POST", "GET", "DELETE", "PUT", "PATCH"}
 	methodAllowed := false
 	for _, m := range allowedMethods {
@@ -644,5 +3658,34 @@ func (c *Client) SendRawRequest(path, method string, data interface{}) ([]byte,
 		return nil, fmt.Errorf("method not allowed")
 	}
 
-	return c.sendRequest(path, method, data, true)
+	// Raw requests carry the client's token, so they must stay on the API host
+	if u, err := url.Parse(path); err != nil || u.IsAbs() || u.Host != "" {
+		return nil, fmt.Errorf("invalid request path %q", path)
+	}
+
+	// A raw request may send anything, so only reads get through a pause
+	if method != "GET" && c.paused.Load() {
+		return nil, ErrClientPaused
+	}
+
+	return c.sendRequest(ctx, path, method, data, true)
+}
+
+// SendRawRequestInto sends a raw request to the API and decodes the response into v
+func (c *Client) SendRawRequestInto(path, method string, data interface{}, v interface{}) error {
+	return c.SendRawRequestIntoContext(context.Background(), path, method, data, v)
+}
+
+// SendRawRequestIntoContext is like SendRawRequestInto but uses ctx for cancellation and deadlines
+func (c *Client) SendRawRequestIntoContext(ctx context.Context, path, method string, data interface{}, v interface{}) error {
+	resp, err := c.SendRawRequestContext(ctx, path, method, data)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(resp, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,2448 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient starts a server running handler and returns a client with a
+// valid token that sends every request to it. Retries are disabled.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c := NewClientWithOptions("user", "secret",
+		WithBaseURL(srv.URL), WithEventsBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{}))
+	c.Token = "token"
+	return c
+}
+
+// writeJSON writes v as the response body
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Errorf("failed to write response: %v", err)
+	}
+}
+
+// readJSON decodes the request body into v
+func readJSON(t *testing.T, r *http.Request, v interface{}) {
+	t.Helper()
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		t.Errorf("failed to decode %s %s body: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+func TestSendRawRequestRejectsAbsoluteURL(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+
+	for _, path := range []string{"https://attacker.example/x", "http://attacker.example/x", "//attacker.example/x"} {
+		if _, err := c.SendRawRequest(path, "GET", nil); err == nil {
+			t.Errorf("SendRawRequest(%q) succeeded, want error", path)
+		}
+	}
+}
+
+func TestSendRawRequest(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/addressbooks/7" {
+			t.Errorf("path = %q, want /addressbooks/7", r.URL.Path)
+		}
+		writeJSON(t, w, []AddressBook{{ID: 7, Name: "News"}})
+	}))
+
+	var books []AddressBook
+	if err := c.SendRawRequestInto("addressbooks/7", "GET", nil, &books); err != nil {
+		t.Fatalf("SendRawRequestInto: %v", err)
+	}
+	if len(books) != 1 || books[0].Name != "News" {
+		t.Errorf("books = %+v", books)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		client  *Client
+		wantErr string
+	}{
+		{"valid", NewClient("user", "secret", filepath.Join(dir, "tokens")), ""},
+		{"valid storage", NewClientWithOptions("user", "secret", WithTokenStorage(FileTokenStorage{Dir: dir})), ""},
+		{"memory store", NewClientWithOptions("user", "secret"), ""},
+		{"empty user id", NewClient("", "secret", dir), "empty user id"},
+		{"empty secret", NewClient("user", "", dir), "empty secret"},
+		{"empty storage path", NewClient("user", "secret", ""), "empty token storage path"},
+		{"empty storage dir", NewClientWithOptions("user", "secret", WithTokenStorage(FileTokenStorage{})), "empty token storage path"},
+		{"storage pointer", NewClientWithOptions("user", "secret", WithTokenStorage(&FileTokenStorage{Dir: dir})), ""},
+		{"empty storage pointer dir", NewClientWithOptions("user", "secret", WithTokenStorage(&FileTokenStorage{})), "empty token storage path"},
+		{"storage pointer is a file", NewClientWithOptions("user", "secret", WithTokenStorage(&FileTokenStorage{Dir: file})), ErrTokenStorageNotDir.Error()},
+		{"file store", NewClientWithStore("user", "secret", NewFileTokenStore(file, "user", "secret")), ErrTokenStorageNotDir.Error()},
+		{"custom storage", NewClientWithOptions("user", "secret", WithTokenStorage(mapTokenStorage{})), ""},
+		{"storage path is a file", NewClient("user", "secret", file), ErrTokenStorageNotDir.Error()},
+		{"storage parent is a file", NewClient("user", "secret", filepath.Join(file, "tokens")), "not a directory"},
+		{"no token store", NewClientWithStore("user", "secret", nil), "no token store configured"},
+		{"no token storage", NewClientWithOptions("user", "secret", WithTokenStorage(nil)), "no token storage configured"},
+		{"invalid base URL", NewClientWithOptions("user", "secret", WithBaseURL("api.sendpulse.com")), "invalid base URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.client.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "tokens")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Validate created the token directory")
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	err := NewClientWithStore("", "", nil).Validate()
+	for _, want := range []string{"empty user id", "empty secret", "no token store configured"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want error containing %q", err, want)
+		}
+	}
+}
+
+func TestValidateUnwritableDir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+
+	err := NewClient("user", "secret", filepath.Join(dir, "tokens")).Validate()
+	if err == nil || !strings.Contains(err.Error(), "not writable") {
+		t.Errorf("Validate() = %v, want a not writable error", err)
+	}
+}
+
+// authHandler issues "fresh" from oauth/access_token, counting fetches, and
+// passes API requests carrying it on to next, rejecting the rest
+func authHandler(t *testing.T, fetches *atomic.Int32, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/access_token" {
+			fetches.Add(1)
+			// Hold the refresh open so concurrent callers pile up behind it
+			time.Sleep(20 * time.Millisecond)
+			writeJSON(t, w, TokenResponse{AccessToken: "fresh", TokenType: "Bearer", ExpiresIn: 3600})
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(t, w, map[string]string{"error": "invalid_token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// emptyList answers every request with an empty JSON array
+var emptyList = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("[]"))
+})
+
+// listConcurrently calls ListAddressBooks from n goroutines at once
+func listConcurrently(t *testing.T, c *Client, n int) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.ListAddressBooks(0, 0); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("ListAddressBooks: %v", err)
+	}
+}
+
+func TestConcurrentRefreshOfExpiredToken(t *testing.T) {
+	var fetches atomic.Int32
+	c := newTestClient(t, authHandler(t, &fetches, emptyList))
+	c.Token = "stale"
+	c.tokenExpiresAt = time.Now().Add(-time.Minute)
+
+	listConcurrently(t, c, 50)
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("token fetched %d times, want 1", n)
+	}
+	if got := c.currentToken(); got != "fresh" {
+		t.Errorf("token = %q, want %q", got, "fresh")
+	}
+}
+
+func TestConcurrentRefreshOn401(t *testing.T) {
+	var fetches atomic.Int32
+	c := newTestClient(t, authHandler(t, &fetches, emptyList))
+
+	// A token with an unknown expiry is only found stale by the 401
+	c.Token = "stale"
+
+	listConcurrently(t, c, 50)
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("token fetched %d times, want 1", n)
+	}
+}
+
+// decodeStringField decodes a request body field holding a JSON-encoded
+// string, as the API expects for email and phone lists
+func decodeStringField(t *testing.T, r *http.Request, field string, v interface{}) {
+	t.Helper()
+
+	var data map[string]interface{}
+	readJSON(t, r, &data)
+	encoded, ok := data[field].(string)
+	if !ok {
+		t.Errorf("%s %s: field %q is %T, want a JSON string", r.Method, r.URL.Path, field, data[field])
+		return
+	}
+	if err := json.Unmarshal([]byte(encoded), v); err != nil {
+		t.Errorf("%s %s: failed to decode %q: %v", r.Method, r.URL.Path, field, err)
+	}
+}
+
+// testEmails returns n distinct addresses
+func testEmails(n int) []string {
+	emails := make([]string, n)
+	for i := range emails {
+		emails[i] = fmt.Sprintf("user%d@example.com", i)
+	}
+	return emails
+}
+
+func TestRemoveEmailsChunks(t *testing.T) {
+	var mu sync.Mutex
+	var chunks [][]string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/addressbooks/5/emails" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		var emails []string
+		decodeStringField(t, r, "emails", &emails)
+		mu.Lock()
+		chunks = append(chunks, emails)
+		mu.Unlock()
+		writeJSON(t, w, map[string]bool{"result": true})
+	}))
+
+	removed, err := c.RemoveEmails(5, testEmails(300))
+	if err != nil {
+		t.Fatalf("RemoveEmails: %v", err)
+	}
+	if removed != 300 {
+		t.Errorf("removed = %d, want 300", removed)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("sent %d DELETE requests, want 3", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if len(chunk) != RemoveEmailsChunkSize {
+			t.Errorf("chunk %d has %d emails, want %d", i, len(chunk), RemoveEmailsChunkSize)
+		}
+	}
+	if chunks[2][0] != "user200@example.com" {
+		t.Errorf("third chunk starts at %s", chunks[2][0])
+	}
+}
+
+func TestRemoveEmailsContinuesPastFailedChunk(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(t, w, map[string]interface{}{"is_error": true, "message": "bad chunk"})
+			return
+		}
+		writeJSON(t, w, map[string]bool{"result": true})
+	}))
+
+	removed, err := c.RemoveEmails(5, testEmails(250))
+	if err == nil || !strings.Contains(err.Error(), "emails 100-199") {
+		t.Errorf("RemoveEmails error = %v, want the failed chunk reported", err)
+	}
+	if removed != 150 {
+		t.Errorf("removed = %d, want 150", removed)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("sent %d requests, want 3", requests.Load())
+	}
+}
+
+// smtpCapture records the emails posted to smtp/emails
+type smtpCapture struct {
+	mu     sync.Mutex
+	emails []smtpEmailPayload
+}
+
+// handler answers each send with an id numbered after the send
+func (s *smtpCapture) handler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/smtp/emails" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			return
+		}
+
+		var email smtpEmailPayload
+		decodeStringField(t, r, "email", &email)
+		s.mu.Lock()
+		s.emails = append(s.emails, email)
+		id := len(s.emails)
+		s.mu.Unlock()
+		writeJSON(t, w, map[string]interface{}{"result": true, "id": fmt.Sprintf("msg-%d", id)})
+	})
+}
+
+func TestSendSeedTest(t *testing.T) {
+	var capture smtpCapture
+	c := newTestClient(t, capture.handler(t))
+
+	seeds := []string{"seed@gmail.com", " Seed@Gmail.com ", "seed@outlook.com", "", "seed@yahoo.com"}
+	results, err := c.SendSeedTest(testEmail("ignored@example.com"), seeds)
+	if err != nil {
+		t.Fatalf("SendSeedTest: %v", err)
+	}
+
+	want := []string{"seed@gmail.com", "seed@outlook.com", "seed@yahoo.com"}
+	if len(capture.emails) != len(want) || len(results) != len(want) {
+		t.Fatalf("sent %d emails with %d results, want one per seed (%d)", len(capture.emails), len(results), len(want))
+	}
+	for i, seed := range want {
+		if to := capture.emails[i].To; len(to) != 1 || to[0].Email != seed {
+			t.Errorf("send %d went to %v, want only %s", i, to, seed)
+		}
+		if results[i].Recipient != seed || results[i].ID != fmt.Sprintf("msg-%d", i+1) {
+			t.Errorf("result %d = %+v", i, results[i])
+		}
+	}
+}
+
+func TestPurgeUnsubscribed(t *testing.T) {
+	var removed []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /addressbooks/5/emails":
+			writeJSON(t, w, []Email{
+				{Email: "active@example.com", Status: EmailStatusActive},
+				{Email: "gone@example.com", Status: EmailStatusUnsubscribed},
+				{Email: "new@example.com", Status: EmailStatusNew},
+				{Email: "left@example.com", Status: EmailStatusUnsubscribed},
+			})
+		case "DELETE /addressbooks/5/emails":
+			decodeStringField(t, r, "emails", &removed)
+			writeJSON(t, w, map[string]bool{"result": true})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	n, err := c.PurgeUnsubscribed(5)
+	if err != nil {
+		t.Fatalf("PurgeUnsubscribed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("purged %d, want 2", n)
+	}
+	if strings.Join(removed, ",") != "gone@example.com,left@example.com" {
+		t.Errorf("removed %v, want the unsubscribed addresses only", removed)
+	}
+}
+
+func TestPurgeUnsubscribedNothingToRemove(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		writeJSON(t, w, []Email{{Email: "active@example.com", Status: EmailStatusActive}})
+	}))
+
+	if n, err := c.PurgeUnsubscribed(5); err != nil || n != 0 {
+		t.Errorf("PurgeUnsubscribed = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestLastTokenResponse(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth/access_token" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		writeJSON(t, w, TokenResponse{AccessToken: "secret-token", TokenType: "Bearer", ExpiresIn: 3600, Scope: "smtp"})
+	}))
+	c.Token = ""
+
+	if c.LastTokenResponse() != nil {
+		t.Error("LastTokenResponse is set before any fetch")
+	}
+	if err := c.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	resp := c.LastTokenResponse()
+	want := TokenResponse{AccessToken: "secret-token", TokenType: "Bearer", ExpiresIn: 3600, Scope: "smtp"}
+	if resp == nil || *resp != want {
+		t.Fatalf("LastTokenResponse = %+v, want %+v", resp, want)
+	}
+
+	// The caller gets a copy
+	resp.AccessToken = "changed"
+	if c.LastTokenResponse().AccessToken != "secret-token" {
+		t.Error("modifying the returned response changed the client's copy")
+	}
+
+	for _, formatted := range []string{fmt.Sprint(*resp), fmt.Sprintf("%v", resp), fmt.Sprintf("%+v", *resp), fmt.Sprintf("%#v", *resp)} {
+		if strings.Contains(formatted, "changed") || !strings.Contains(formatted, "REDACTED") {
+			t.Errorf("formatted token response exposes the token: %s", formatted)
+		}
+	}
+}
+
+// noRequests fails the test on any request
+func noRequests(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+}
+
+func TestOversizedAttachmentsRejected(t *testing.T) {
+	c := newTestClient(t, noRequests(t))
+	c.MaxAttachmentSize = 1000
+
+	// 2 x 450 raw bytes encode to 2 x 600 bytes, over the limit
+	attachments := []Attachment{
+		{Filename: "a.pdf", Content: make([]byte, 450)},
+		{Filename: "b.pdf", Content: make([]byte, 450)},
+	}
+
+	email := testEmail("a@example.com")
+	email.Attachments = attachments
+	if _, err := c.SMTPSend(email); !errors.Is(err, ErrAttachmentsTooLarge) {
+		t.Errorf("SMTPSend error = %v, want ErrAttachmentsTooLarge", err)
+	}
+
+	if _, err := c.CreateCampaignWithAttachments("Me", "me@example.com", "Hi", "<p>Hi</p>", 5, "", attachments); !errors.Is(err, ErrAttachmentsTooLarge) {
+		t.Errorf("CreateCampaignWithAttachments error = %v, want ErrAttachmentsTooLarge", err)
+	}
+
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "a.pdf"), filepath.Join(dir, "b.pdf")}
+	for _, path := range paths {
+		if err := os.WriteFile(path, make([]byte, 450), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.SMTPSendMailWithAttachments(testEmail("a@example.com"), paths); !errors.Is(err, ErrAttachmentsTooLarge) {
+		t.Errorf("SMTPSendMailWithAttachments error = %v, want ErrAttachmentsTooLarge", err)
+	}
+}
+
+func TestAttachmentsWithinLimit(t *testing.T) {
+	var capture smtpCapture
+	c := newTestClient(t, capture.handler(t))
+	c.MaxAttachmentSize = 1200
+
+	email := testEmail("a@example.com")
+	email.Attachments = []Attachment{
+		{Filename: "a.pdf", Content: make([]byte, 450)},
+		{Filename: "b.pdf", Content: make([]byte, 450)},
+	}
+	if _, err := c.SMTPSend(email); err != nil {
+		t.Fatalf("SMTPSend: %v", err)
+	}
+
+	// A zero limit disables the check
+	c.MaxAttachmentSize = 0
+	email.Attachments = append(email.Attachments, Attachment{Filename: "c.pdf", Content: make([]byte, 4096)})
+	if _, err := c.SMTPSend(email); err != nil {
+		t.Fatalf("SMTPSend without a limit: %v", err)
+	}
+
+	if len(capture.emails) != 2 || len(capture.emails[1].AttachmentsBinary) != 3 {
+		t.Errorf("sent %d emails, want 2 with all attachments", len(capture.emails))
+	}
+}
+
+func TestSMTPSendCharset(t *testing.T) {
+	var capture smtpCapture
+	c := newTestClient(t, capture.handler(t))
+
+	if _, err := c.SMTPSend(testEmail("a@example.com")); err != nil {
+		t.Fatalf("SMTPSend: %v", err)
+	}
+
+	email := testEmail("a@example.com")
+	email.Charset = "ISO-8859-1"
+	email.ContentType = "text/plain"
+	if _, err := c.SMTPSend(email); err != nil {
+		t.Fatalf("SMTPSend: %v", err)
+	}
+
+	if got := capture.emails[0]; got.Charset != DefaultCharset || got.ContentType != DefaultContentType {
+		t.Errorf("default payload charset = %q, content type = %q", got.Charset, got.ContentType)
+	}
+	if got := capture.emails[1]; got.Charset != "ISO-8859-1" || got.ContentType != "text/plain" {
+		t.Errorf("payload charset = %q, content type = %q", got.Charset, got.ContentType)
+	}
+}
+
+func TestSMSSendWithResult(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/sms/send" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"result":true,"campaign_id":9876,"campaign_cost":1.25,"currency":"EUR"}`))
+	}))
+
+	result, err := c.SMSSendWithResult("Shop", []string{"+49 151 1234567"}, "Your code is 1234", nil, false, "")
+	if err != nil {
+		t.Fatalf("SMSSendWithResult: %v", err)
+	}
+	want := SMSSendResult{CampaignID: 9876, Cost: 1.25, Currency: "EUR"}
+	if *result != want {
+		t.Errorf("result = %+v, want %+v", *result, want)
+	}
+
+	// The error-only wrapper still works
+	if err := c.SMSSend("Shop", []string{"+49 151 1234567"}, "Your code is 1234", nil, false, ""); err != nil {
+		t.Errorf("SMSSend: %v", err)
+	}
+}
+
+// smsBlacklistHandler reports the numbers in blacklist as blacklisted and
+// records the numbers sent with sms/send
+func smsBlacklistHandler(t *testing.T, blacklist map[string]bool, sent *[]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /sms/black_list/by_numbers":
+			var phones []string
+			if err := json.Unmarshal([]byte(r.URL.Query().Get("phones")), &phones); err != nil {
+				t.Errorf("invalid phones parameter: %v", err)
+			}
+
+			var data []Phone
+			for _, phone := range phones {
+				if blacklist[phone] {
+					data = append(data, Phone{Phone: phone})
+				}
+			}
+			writeJSON(t, w, map[string]interface{}{"data": data})
+		case "POST /sms/send":
+			decodeStringField(t, r, "phones", sent)
+			writeJSON(t, w, map[string]interface{}{"result": true, "campaign_id": 1})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+}
+
+func TestSMSIsBlacklisted(t *testing.T) {
+	c := newTestClient(t, smsBlacklistHandler(t, map[string]bool{"491511111111": true}, nil))
+
+	tests := []struct {
+		phone string
+		want  bool
+	}{
+		{"+49 151 111-1111", true},
+		{"491511111111", true},
+		{"+49 151 222-2222", false},
+	}
+	for _, tt := range tests {
+		got, err := c.SMSIsBlacklisted(tt.phone)
+		if err != nil {
+			t.Fatalf("SMSIsBlacklisted(%q): %v", tt.phone, err)
+		}
+		if got != tt.want {
+			t.Errorf("SMSIsBlacklisted(%q) = %v, want %v", tt.phone, got, tt.want)
+		}
+	}
+
+	if _, err := c.SMSIsBlacklisted("n/a"); err == nil {
+		t.Error("SMSIsBlacklisted accepted a number without digits")
+	}
+}
+
+func TestSMSSendSkipsBlacklistedPhones(t *testing.T) {
+	var sent []string
+	c := newTestClient(t, smsBlacklistHandler(t, map[string]bool{"491511111111": true}, &sent))
+	c.SkipBlacklistedPhones = true
+
+	if err := c.SMSSend("Shop", []string{"+49 151 111-1111", "+49 151 222-2222"}, "Hi", nil, false, ""); err != nil {
+		t.Fatalf("SMSSend: %v", err)
+	}
+	if len(sent) != 1 || sent[0] != "+49 151 222-2222" {
+		t.Errorf("sent to %v, want only the clean number", sent)
+	}
+
+	if err := c.SMSSend("Shop", []string{"+49 151 111-1111"}, "Hi", nil, false, ""); err == nil {
+		t.Error("SMSSend succeeded with only blacklisted numbers")
+	}
+}
+
+func TestDecodeListTotal(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		header string
+		total  int
+		count  int
+	}{
+		{"object", `{"data":[{"id":1},{"id":2}],"total":57}`, "", 57, 2},
+		{"object without total", `{"data":[{"id":1},{"id":2}]}`, "", -1, 2},
+		{"object with zero total", `{"data":[],"total":0}`, "", 0, 0},
+		{"array with header", `[{"id":1}]`, "12", 12, 1},
+		{"array without header", `[{"id":1}]`, "", -1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.header != "" {
+				header.Set("X-Total-Count", tt.header)
+			}
+
+			var books []AddressBook
+			total, err := decodeList([]byte(tt.body), header, &books)
+			if err != nil {
+				t.Fatalf("decodeList: %v", err)
+			}
+			if total != tt.total || len(books) != tt.count {
+				t.Errorf("got %d items, total %d; want %d items, total %d", len(books), total, tt.count, tt.total)
+			}
+		})
+	}
+}
+
+func TestListAddressBooksWithTotal(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("limit") != "2" || q.Get("offset") != "4" {
+			t.Errorf("query = %q, want limit=2 and offset=4", r.URL.RawQuery)
+		}
+		w.Header().Set("X-Total-Count", "5")
+		writeJSON(t, w, []AddressBook{{ID: 5}})
+	}))
+
+	books, total, err := c.ListAddressBooksWithTotal(2, 4)
+	if err != nil {
+		t.Fatalf("ListAddressBooksWithTotal: %v", err)
+	}
+	if len(books) != 1 || total != 5 {
+		t.Errorf("got %d books, total %d; want 1, 5", len(books), total)
+	}
+}
+
+func TestIterateAddressBooksStopsAtTotal(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		writeJSON(t, w, map[string]interface{}{
+			"data":  []AddressBook{{ID: offset + 1}, {ID: offset + 2}},
+			"total": 4,
+		})
+	}))
+
+	var ids []int
+	err := c.IterateAddressBooks(context.Background(), 2, func(b AddressBook) error {
+		ids = append(ids, b.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("IterateAddressBooks: %v", err)
+	}
+
+	// Full pages alone can't tell the iterator it has reached the end
+	if len(ids) != 4 || requests.Load() != 2 {
+		t.Errorf("got ids %v in %d requests, want 4 ids in 2 requests", ids, requests.Load())
+	}
+}
+
+func TestIterateAddressBooksWithoutTotal(t *testing.T) {
+	books := []AddressBook{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	var requests atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		end := min(offset+2, len(books))
+		writeJSON(t, w, map[string]interface{}{"data": books[min(offset, end):end]})
+	}))
+
+	var ids []int
+	err := c.IterateAddressBooks(context.Background(), 2, func(b AddressBook) error {
+		ids = append(ids, b.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("IterateAddressBooks: %v", err)
+	}
+
+	// Without a total only a short page ends the iteration
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(ids, want) || requests.Load() != 2 {
+		t.Errorf("got ids %v in %d requests, want %v in 2 requests", ids, requests.Load(), want)
+	}
+
+	_, total, err := c.ListAddressBooksWithTotal(2, 0)
+	if err != nil || total != -1 {
+		t.Errorf("ListAddressBooksWithTotal = total %d, %v; want -1", total, err)
+	}
+}
+
+func TestCloneBookStructure(t *testing.T) {
+	source := []BookVariable{{Name: "first_name", Type: "string"}, {Name: "birthday", Type: "date"}}
+	var copied []BookVariable
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /addressbooks/5/variables":
+			writeJSON(t, w, source)
+		case "POST /addressbooks":
+			var data map[string]string
+			readJSON(t, r, &data)
+			if data["bookName"] != "Segment B" {
+				t.Errorf("created book %q, want Segment B", data["bookName"])
+			}
+			writeJSON(t, w, AddressBook{ID: 9, Name: "Segment B"})
+		case "POST /addressbooks/9/variables":
+			var v BookVariable
+			readJSON(t, r, &v)
+			copied = append(copied, v)
+			writeJSON(t, w, map[string]bool{"result": true})
+		default:
+			// Subscribers must not be read from the source or added to the copy
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	book, err := c.CloneBookStructure(5, "Segment B")
+	if err != nil {
+		t.Fatalf("CloneBookStructure: %v", err)
+	}
+	if book.ID != 9 {
+		t.Errorf("book id = %d, want 9", book.ID)
+	}
+	if !reflect.DeepEqual(copied, source) {
+		t.Errorf("copied variables %v, want %v", copied, source)
+	}
+}
+
+func TestGetBookForTemplating(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /addressbooks/5/emails":
+			writeJSON(t, w, []Email{{Email: "a@example.com", Variables: map[string]interface{}{"name": "Ann"}}})
+		case "GET /addressbooks/5/variables":
+			writeJSON(t, w, []BookVariable{{Name: "name", Type: "string"}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	export, err := c.GetBookForTemplating(5)
+	if err != nil {
+		t.Fatalf("GetBookForTemplating: %v", err)
+	}
+	if export.BookID != 5 {
+		t.Errorf("book id = %d, want 5", export.BookID)
+	}
+	if len(export.Emails) != 1 || export.Emails[0].Variables["name"] != "Ann" {
+		t.Errorf("emails = %+v", export.Emails)
+	}
+	if len(export.Variables) != 1 || export.Variables[0].Name != "name" {
+		t.Errorf("variables = %+v", export.Variables)
+	}
+}
+
+func TestDefaultSender(t *testing.T) {
+	var capture smtpCapture
+	c := newTestClient(t, capture.handler(t))
+	c.SetDefaultSender(Contact{Name: "Shop", Email: "shop@example.com"})
+
+	email := testEmail("a@example.com")
+	email.From = Contact{}
+	if _, err := c.SMTPSend(email); err != nil {
+		t.Fatalf("SMTPSend: %v", err)
+	}
+
+	email.From = Contact{Name: "Support", Email: "support@example.com"}
+	if _, err := c.SMTPSend(email); err != nil {
+		t.Fatalf("SMTPSend: %v", err)
+	}
+
+	if got := capture.emails[0].From; got != (Contact{Name: "Shop", Email: "shop@example.com"}) {
+		t.Errorf("omitted from = %+v, want the default sender", got)
+	}
+	if got := capture.emails[1].From; got != (Contact{Name: "Support", Email: "support@example.com"}) {
+		t.Errorf("explicit from = %+v, want it to override the default", got)
+	}
+}
+
+func TestSMTPSendInlineAttachments(t *testing.T) {
+	var capture smtpCapture
+	c := newTestClient(t, capture.handler(t))
+
+	email := testEmail("a@example.com")
+	email.HTML = `<img src="cid:logo">`
+	email.Attachments = []Attachment{
+		{Filename: "logo.png", ContentType: "image/png", Content: []byte("png"), ContentID: "logo"},
+		{Filename: "terms.pdf", Content: []byte("pdf")},
+	}
+	if _, err := c.SMTPSend(email); err != nil {
+		t.Fatalf("SMTPSend: %v", err)
+	}
+
+	sent := capture.emails[0]
+	wantInline := []inlineAttachmentPayload{{
+		ContentID:   "logo",
+		Filename:    "logo.png",
+		ContentType: "image/png",
+		Content:     base64.StdEncoding.EncodeToString([]byte("png")),
+	}}
+	if !reflect.DeepEqual(sent.InlineAttachments, wantInline) {
+		t.Errorf("inline attachments = %+v, want %+v", sent.InlineAttachments, wantInline)
+	}
+
+	wantBinary := map[string]string{"terms.pdf": base64.StdEncoding.EncodeToString([]byte("pdf"))}
+	if !reflect.DeepEqual(sent.AttachmentsBinary, wantBinary) {
+		t.Errorf("regular attachments = %v, want %v", sent.AttachmentsBinary, wantBinary)
+	}
+}
+
+func TestSMSAddPhonesMixedResult(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/sms/numbers" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"result":true,"counters":{"added":2,"exceptions":1},"exceptions":{"123":"invalid number"}}`))
+	}))
+
+	result, err := c.SMSAddPhones(5, []string{"491511111111", "491512222222", "123"})
+	if err != nil {
+		t.Fatalf("SMSAddPhones: %v", err)
+	}
+	if result.Added != 2 || result.Failed != 1 {
+		t.Errorf("added %d, failed %d; want 2, 1", result.Added, result.Failed)
+	}
+	if result.Failures["123"] != "invalid number" || len(result.Failures) != 1 {
+		t.Errorf("failures = %v", result.Failures)
+	}
+	if len(result.Unsent) != 0 {
+		t.Errorf("unsent = %v, want none", result.Unsent)
+	}
+}
+
+func TestSMSGetMessageStatus(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sms/messages/m-1":
+			w.Write([]byte(`{"data":{"id":"m-1","phone":"491511111111","status":"delivered","updated":"2024-03-01 10:15:00"}}`))
+		case "/sms/messages/m-2":
+			w.Write([]byte(`{"data":{"id":"m-2","phone":"491512222222","status":"failed","updated":"2024-03-01 10:16:30"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	tests := []struct {
+		id      string
+		status  string
+		updated time.Time
+	}{
+		{"m-1", SMSStatusDelivered, time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC)},
+		{"m-2", SMSStatusFailed, time.Date(2024, 3, 1, 10, 16, 30, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		status, err := c.SMSGetMessageStatus(tt.id)
+		if err != nil {
+			t.Fatalf("SMSGetMessageStatus(%s): %v", tt.id, err)
+		}
+		if status.MessageID != tt.id || status.Status != tt.status || !status.UpdatedAt.Equal(tt.updated) {
+			t.Errorf("SMSGetMessageStatus(%s) = %+v", tt.id, status)
+		}
+	}
+}
+
+func TestGetCampaignSchedule(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/campaigns/3":
+			writeJSON(t, w, Campaign{ID: 3, SendDate: "2024-06-01 09:30:00"})
+		case "/campaigns/4":
+			writeJSON(t, w, Campaign{ID: 4})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	c.Timezone = berlin
+
+	at, err := c.GetCampaignSchedule(3)
+	if err != nil {
+		t.Fatalf("GetCampaignSchedule: %v", err)
+	}
+	if want := time.Date(2024, 6, 1, 7, 30, 0, 0, time.UTC); !at.Equal(want) {
+		t.Errorf("schedule = %v, want %v", at, want)
+	}
+
+	if _, err := c.GetCampaignSchedule(4); err == nil {
+		t.Error("GetCampaignSchedule succeeded for an unscheduled campaign")
+	}
+	if _, err := c.GetCampaignSchedule(0); err == nil {
+		t.Error("GetCampaignSchedule(0) succeeded, want error")
+	}
+}
+
+func TestDeleteCampaigns(t *testing.T) {
+	var active, peak atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/campaigns/"))
+		if id%3 == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(t, w, map[string]interface{}{"is_error": true, "message": "campaign not found"})
+			return
+		}
+		writeJSON(t, w, map[string]bool{"result": true})
+	}))
+
+	ids := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	results, err := c.DeleteCampaigns(ids)
+	if err == nil || !strings.Contains(err.Error(), "4 of 12") {
+		t.Errorf("DeleteCampaigns error = %v, want 4 of 12 failed", err)
+	}
+
+	if len(results) != len(ids) {
+		t.Fatalf("got %d results, want %d", len(results), len(ids))
+	}
+	for _, id := range ids {
+		var apiErr *APIError
+		failed := errors.As(results[id], &apiErr) && apiErr.StatusCode == http.StatusNotFound
+		if failed != (id%3 == 0) || (!failed && results[id] != nil) {
+			t.Errorf("campaign %d: %v", id, results[id])
+		}
+	}
+
+	if p := peak.Load(); p > deleteConcurrency {
+		t.Errorf("%d deletions ran at once, want at most %d", p, deleteConcurrency)
+	}
+}
+
+func TestRetryableErrorCode(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// A 2xx status with an error body
+			writeJSON(t, w, map[string]interface{}{"is_error": true, "error_code": 711, "message": "temporarily locked"})
+			return
+		}
+		writeJSON(t, w, []AddressBook{{ID: 1}})
+	}))
+	c.SetRetryableErrorCodes([]int{711})
+
+	books, err := c.ListAddressBooks(0, 0)
+	if err != nil {
+		t.Fatalf("ListAddressBooks: %v", err)
+	}
+	if len(books) != 1 || requests.Load() != 2 {
+		t.Errorf("got %d books after %d requests, want 1 book after 2", len(books), requests.Load())
+	}
+}
+
+func TestNonRetryableErrorCode(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeJSON(t, w, map[string]interface{}{"is_error": true, "error_code": 12, "message": "book not found"})
+	}))
+	c.SetRetryableErrorCodes([]int{711})
+
+	_, err := c.ListAddressBooks(0, 0)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode != 12 {
+		t.Errorf("ListAddressBooks error = %v, want APIError with code 12", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("sent %d requests, want 1", requests.Load())
+	}
+}
+
+func TestGetMergedContact(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/emails/ann@example.com" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		writeJSON(t, w, []EmailBookInfo{
+			{BookID: 1, Email: "ann@example.com", Variables: map[string]interface{}{"name": "Ann", "plan": "free"}},
+			{BookID: 2, Email: "ann@example.com", Variables: map[string]interface{}{"plan": "pro", "city": "Berlin"}},
+		})
+	}))
+
+	merged, err := c.GetMergedContact("ann@example.com")
+	if err != nil {
+		t.Fatalf("GetMergedContact: %v", err)
+	}
+	want := map[string]interface{}{"name": "Ann", "plan": "pro", "city": "Berlin"}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("last wins merge = %v, want %v", merged, want)
+	}
+
+	merged, err = c.GetMergedContactWithStrategy("ann@example.com", MergeFirstWins)
+	if err != nil {
+		t.Fatalf("GetMergedContactWithStrategy: %v", err)
+	}
+	want["plan"] = "free"
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("first wins merge = %v, want %v", merged, want)
+	}
+}
+
+func TestGetBookGrowthStats(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/addressbooks/5/stats" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		q := r.URL.Query()
+		if q.Get("date_from") == "2024-01-01" {
+			writeJSON(t, w, []interface{}{})
+			return
+		}
+		if q.Get("date_from") != "2024-03-01" || q.Get("date_to") != "2024-03-03" {
+			t.Errorf("query = %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`[
+			{"date":"2024-03-01","subscribed":10,"unsubscribed":2},
+			{"date":"2024-03-02","subscribed":0,"unsubscribed":5},
+			{"date":"2024-03-03","subscribed":7,"unsubscribed":0}
+		]`))
+	}))
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	points, err := c.GetBookGrowthStats(5, from, from.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("GetBookGrowthStats: %v", err)
+	}
+
+	want := []BookGrowthPoint{
+		{Date: from, Added: 10, Removed: 2, Delta: 8},
+		{Date: from.AddDate(0, 0, 1), Added: 0, Removed: 5, Delta: -5},
+		{Date: from.AddDate(0, 0, 2), Added: 7, Removed: 0, Delta: 7},
+	}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("points = %+v, want %+v", points, want)
+	}
+
+	empty := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points, err = c.GetBookGrowthStats(5, empty, empty.AddDate(0, 0, 6))
+	if err != nil || len(points) != 0 {
+		t.Errorf("empty range = %v, %v; want no points", points, err)
+	}
+
+	if _, err := c.GetBookGrowthStats(5, from, from.AddDate(0, 0, -1)); err == nil {
+		t.Error("GetBookGrowthStats accepted an inverted range")
+	}
+}
+
+func TestListCampaignSummaries(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/campaigns" || r.URL.Query().Get("limit") != "2" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		w.Header().Set("X-Total-Count", "14")
+		writeJSON(t, w, []Campaign{
+			{ID: 1, Name: "Spring", Status: "3", Subject: "Sale", AllEmailQty: 1200},
+			{ID: 2, Name: "Summer", Status: "1", AllEmailQty: 0},
+		})
+	}))
+
+	summaries, total, err := c.ListCampaignSummaries(2, 0)
+	if err != nil {
+		t.Fatalf("ListCampaignSummaries: %v", err)
+	}
+	want := []CampaignSummary{
+		{ID: 1, Name: "Spring", Status: "3", SentCount: 1200},
+		{ID: 2, Name: "Summer", Status: "1", SentCount: 0},
+	}
+	if !reflect.DeepEqual(summaries, want) || total != 14 {
+		t.Errorf("got %+v, total %d; want %+v, total 14", summaries, total, want)
+	}
+}
+
+func TestGetEmailBooks(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/emails/ann@example.com":
+			writeJSON(t, w, []EmailBookInfo{{BookID: 1, Email: "ann@example.com"}, {BookID: 4, Email: "ann@example.com"}})
+		case "/addressbooks/1":
+			writeJSON(t, w, AddressBook{ID: 1, Name: "Customers"})
+		case "/addressbooks/4":
+			writeJSON(t, w, AddressBook{ID: 4, Name: "Newsletter"})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	books, err := c.GetEmailBooks("ann@example.com")
+	if err != nil {
+		t.Fatalf("GetEmailBooks: %v", err)
+	}
+	want := []AddressBook{{ID: 1, Name: "Customers"}, {ID: 4, Name: "Newsletter"}}
+	if !reflect.DeepEqual(books, want) {
+		t.Errorf("books = %+v, want %+v", books, want)
+	}
+}
+
+func TestAddEmailsWithValidation(t *testing.T) {
+	var validated []interface{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/addressbooks/5/emails" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		var data map[string]interface{}
+		readJSON(t, r, &data)
+		validated = append(validated, data["validate"])
+		writeJSON(t, w, map[string]interface{}{"result": true, "rejected_emails": []string{"bad@example"}})
+	}))
+
+	emails := []Email{{Email: "good@example.com"}, {Email: "bad@example"}, {Email: "other@example.com"}}
+	result, err := c.AddEmails(5, emails, WithValidation(true))
+	if err != nil {
+		t.Fatalf("AddEmails: %v", err)
+	}
+	if result.Added != 2 || len(result.Rejected) != 1 || result.Rejected[0] != "bad@example" {
+		t.Errorf("result = %+v, want 2 added and bad@example rejected", result)
+	}
+
+	if _, err := c.AddEmails(5, emails); err != nil {
+		t.Fatalf("AddEmails: %v", err)
+	}
+	if len(validated) != 2 || validated[0] != 1.0 || validated[1] != nil {
+		t.Errorf("validate flags = %v, want [1 <nil>]", validated)
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		in   string
+		opts NormalizeOptions
+		want string
+	}{
+		{"  Ann.Smith@Example.COM ", NormalizeOptions{}, "ann.smith@example.com"},
+		{"ann+news@example.com", NormalizeOptions{}, "ann+news@example.com"},
+		{"ann+news@example.com", NormalizeOptions{StripSubaddress: true}, "ann@example.com"},
+		{"Ann.Smith@Gmail.com", NormalizeOptions{}, "ann.smith@gmail.com"},
+		{"Ann.Smith@Gmail.com", NormalizeOptions{StripGmailDots: true}, "annsmith@gmail.com"},
+		{"ann.smith@googlemail.com", NormalizeOptions{StripGmailDots: true}, "annsmith@googlemail.com"},
+		{"ann.smith@example.com", NormalizeOptions{StripGmailDots: true}, "ann.smith@example.com"},
+		{"A.nn+x@gmail.com", NormalizeOptions{StripSubaddress: true, StripGmailDots: true}, "ann@gmail.com"},
+		{"not-an-email", NormalizeOptions{StripSubaddress: true}, "not-an-email"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeEmail(tt.in, tt.opts); got != tt.want {
+			t.Errorf("NormalizeEmail(%q, %+v) = %q, want %q", tt.in, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestExportCampaignsCSV(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/campaigns" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		writeJSON(t, w, []Campaign{
+			{ID: 1, Name: "Spring", Status: "3", SenderName: "Shop", SenderEmail: "shop@example.com", Subject: "Sale, today", AllEmailQty: 1200},
+			{ID: 2, Name: "Summer", Status: "1", SenderName: "Shop", SenderEmail: "shop@example.com", Subject: "Hot"},
+		})
+	}))
+
+	var buf strings.Builder
+	if err := c.ExportCampaignsCSV(&buf); err != nil {
+		t.Fatalf("ExportCampaignsCSV: %v", err)
+	}
+
+	want := "id,name,status,sender_name,sender_email,subject,sent\n" +
+		"1,Spring,3,Shop,shop@example.com,\"Sale, today\",1200\n" +
+		"2,Summer,1,Shop,shop@example.com,Hot,0\n"
+	if buf.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestGetCampaignVariables(t *testing.T) {
+	body := "<p>Hi {{name}}, your {{ plan }} renews on {{renewal.date}}.</p><p>Bye {{name}}</p>"
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/campaigns/3" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		writeJSON(t, w, Campaign{ID: 3, Message: &CampaignMessage{Body: body}})
+	}))
+
+	variables, err := c.GetCampaignVariables(3)
+	if err != nil {
+		t.Fatalf("GetCampaignVariables: %v", err)
+	}
+	if want := []string{"name", "plan", "renewal.date"}; !reflect.DeepEqual(variables, want) {
+		t.Errorf("variables = %v, want %v", variables, want)
+	}
+}
+
+func TestGetCampaignVariablesBodyNotDecoded(t *testing.T) {
+	// An encoded body is not guessed at, so its placeholders stay hidden
+	encoded := base64.StdEncoding.EncodeToString([]byte("<p>Hi {{name}}</p>"))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, Campaign{ID: 3, Message: &CampaignMessage{Body: encoded}})
+	}))
+
+	variables, err := c.GetCampaignVariables(3)
+	if err != nil {
+		t.Fatalf("GetCampaignVariables: %v", err)
+	}
+	if len(variables) != 0 {
+		t.Errorf("variables = %v, want none from an undecoded body", variables)
+	}
+}
+
+func TestSMTPSendTrackingFlags(t *testing.T) {
+	var sent []map[string]interface{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var email map[string]interface{}
+		decodeStringField(t, r, "email", &email)
+		sent = append(sent, email)
+		writeJSON(t, w, map[string]interface{}{"result": true, "id": "msg"})
+	}))
+
+	if _, err := c.SMTPSend(testEmail("a@example.com")); err != nil {
+		t.Fatalf("SMTPSend: %v", err)
+	}
+
+	off, on := false, true
+	email := testEmail("a@example.com")
+	email.TrackOpens = &off
+	email.TrackClicks = &on
+	if _, err := c.SMTPSend(email); err != nil {
+		t.Fatalf("SMTPSend: %v", err)
+	}
+
+	for _, key := range []string{"track_opens", "track_clicks"} {
+		if _, ok := sent[0][key]; ok {
+			t.Errorf("%s sent without being set", key)
+		}
+	}
+	if sent[1]["track_opens"] != false || sent[1]["track_clicks"] != true {
+		t.Errorf("tracking flags = %v, %v; want false, true", sent[1]["track_opens"], sent[1]["track_clicks"])
+	}
+}
+
+func TestEditAddressBook(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want AddressBook
+	}{
+		{"empty body", "", AddressBook{ID: 5, Name: "Renamed"}},
+		{"result flag", `{"result":true}`, AddressBook{ID: 5, Name: "Renamed"}},
+		{"book", `{"id":5,"name":"Renamed (1)"}`, AddressBook{ID: 5, Name: "Renamed (1)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "PUT" || r.URL.Path != "/addressbooks/5" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+
+				var data map[string]string
+				readJSON(t, r, &data)
+				if data["name"] != "Renamed" {
+					t.Errorf("name = %q, want Renamed", data["name"])
+				}
+				w.Write([]byte(tt.body))
+			}))
+
+			book, err := c.EditAddressBook(5, "Renamed")
+			if err != nil {
+				t.Fatalf("EditAddressBook: %v", err)
+			}
+			if *book != tt.want {
+				t.Errorf("book = %+v, want %+v", *book, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditAddressBookRejected(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":false}`))
+	}))
+
+	if _, err := c.EditAddressBook(5, "Renamed"); err == nil {
+		t.Error("EditAddressBook succeeded with result false")
+	}
+}
+
+func TestGetBalanceHistory(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/balance/history" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if q := r.URL.Query(); q.Get("from") != "2024-03-01" || q.Get("to") != "2024-03-31" {
+			t.Errorf("query = %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`[
+			{"amount":50,"currency":"EUR","type":"credit","description":"Top-up","date":"2024-03-01 09:00:00"},
+			{"amount":-1.25,"currency":"EUR","type":"debit","description":"SMS campaign","date":"2024-03-02 12:30:00"},
+			{"amount":-4.5,"currency":"EUR","type":"debit","description":"Email verification","date":1709900000}
+		]`))
+	}))
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	transactions, err := c.GetBalanceHistory(from, from.AddDate(0, 0, 30))
+	if err != nil {
+		t.Fatalf("GetBalanceHistory: %v", err)
+	}
+
+	want := []BalanceTransaction{
+		{Amount: 50, Currency: "EUR", Type: "credit", Description: "Top-up", Time: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)},
+		{Amount: -1.25, Currency: "EUR", Type: "debit", Description: "SMS campaign", Time: time.Date(2024, 3, 2, 12, 30, 0, 0, time.UTC)},
+		{Amount: -4.5, Currency: "EUR", Type: "debit", Description: "Email verification", Time: time.Unix(1709900000, 0).UTC()},
+	}
+	if !reflect.DeepEqual(transactions, want) {
+		t.Errorf("transactions = %+v, want %+v", transactions, want)
+	}
+}
+
+// bookHandler serves the emails of book 5 honoring limit and offset, and
+// records each page requested
+func bookHandler(t *testing.T, emails []Email, pages *[]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/addressbooks/5/emails" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			return
+		}
+		*pages = append(*pages, r.URL.RawQuery)
+
+		q := r.URL.Query()
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		end := len(emails)
+		if limit > 0 {
+			end = min(offset+limit, end)
+		}
+		writeJSON(t, w, emails[min(offset, end):end])
+	})
+}
+
+func TestDiscoverVariables(t *testing.T) {
+	emails := make([]Email, 250)
+	for i := range emails {
+		emails[i] = Email{Email: fmt.Sprintf("user%d@example.com", i), Variables: map[string]interface{}{"name": "x"}}
+	}
+	emails[3].Variables["plan"] = "pro"
+	emails[120].Variables["city"] = "Berlin"
+	emails[200].Variables["late"] = true
+
+	var pages []string
+	c := newTestClient(t, bookHandler(t, emails, &pages))
+
+	variables, err := c.DiscoverVariables(5)
+	if err != nil {
+		t.Fatalf("DiscoverVariables: %v", err)
+	}
+	if want := []string{"city", "late", "name", "plan"}; !reflect.DeepEqual(variables, want) {
+		t.Errorf("variables = %v, want %v", variables, want)
+	}
+
+	// The sample cap stops before the contact setting "late"
+	pages = nil
+	variables, err = c.DiscoverVariables(5, WithSampleSize(150))
+	if err != nil {
+		t.Fatalf("DiscoverVariables: %v", err)
+	}
+	if want := []string{"city", "name", "plan"}; !reflect.DeepEqual(variables, want) {
+		t.Errorf("sampled variables = %v, want %v", variables, want)
+	}
+	if want := []string{"limit=100", "limit=50&offset=100"}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %q, want %q", pages, want)
+	}
+}
+
+func TestSendCampaignTest(t *testing.T) {
+	var requests int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != "POST" || r.URL.Path != "/campaigns/3/test" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		var data struct {
+			Emails []string `json:"emails"`
+		}
+		readJSON(t, r, &data)
+		if want := []string{"proof@example.com", "boss@example.com"}; !reflect.DeepEqual(data.Emails, want) {
+			t.Errorf("emails = %v, want %v", data.Emails, want)
+		}
+		writeJSON(t, w, map[string]bool{"result": true})
+	}))
+
+	if err := c.SendCampaignTest(3, []string{"proof@example.com", "boss@example.com"}); err != nil {
+		t.Fatalf("SendCampaignTest: %v", err)
+	}
+
+	for _, emails := range [][]string{nil, {"proof@example.com", "not-an-email"}} {
+		if err := c.SendCampaignTest(3, emails); err == nil {
+			t.Errorf("SendCampaignTest(%v) succeeded, want error", emails)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("sent %d requests, want 1", requests)
+	}
+}
+
+func TestGetCampaignRecipients(t *testing.T) {
+	recipients := make([]RecipientStatus, 250)
+	for i := range recipients {
+		recipients[i] = RecipientStatus{Email: fmt.Sprintf("user%d@example.com", i), Status: RecipientOpened, Opened: true}
+	}
+
+	var offsets []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/campaigns/7/recipients" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("status") != RecipientOpened || q.Get("limit") != "100" {
+			t.Errorf("query = %q", r.URL.RawQuery)
+		}
+		offsets = append(offsets, q.Get("offset"))
+
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		end := min(offset+100, len(recipients))
+		writeJSON(t, w, recipients[min(offset, end):end])
+	}))
+
+	got, err := c.GetCampaignRecipients(7, RecipientOpened)
+	if err != nil {
+		t.Fatalf("GetCampaignRecipients: %v", err)
+	}
+	if len(got) != len(recipients) || got[0].Email != "user0@example.com" || got[249].Email != "user249@example.com" {
+		t.Errorf("got %d recipients, want %d in order", len(got), len(recipients))
+	}
+	if want := []string{"", "100", "200"}; !reflect.DeepEqual(offsets, want) {
+		t.Errorf("offsets = %v, want %v", offsets, want)
+	}
+}
+
+func TestGetCampaignRecipientsInvalid(t *testing.T) {
+	c := newTestClient(t, noRequests(t))
+
+	if _, err := c.GetCampaignRecipients(0, ""); err == nil {
+		t.Error("GetCampaignRecipients with empty id succeeded, want error")
+	}
+	if _, err := c.GetCampaignRecipients(7, "unsubscribed"); err == nil {
+		t.Error("GetCampaignRecipients with unknown filter succeeded, want error")
+	}
+}
+
+func TestWithTokenOverride(t *testing.T) {
+	var fetches atomic.Int32
+	var auth []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/access_token" {
+			fetches.Add(1)
+			writeJSON(t, w, TokenResponse{AccessToken: "fresh", TokenType: "Bearer", ExpiresIn: 3600})
+			return
+		}
+		auth = append(auth, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer expired" {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(t, w, map[string]string{"error": "invalid_token"})
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+
+	if _, err := c.ListAddressBooksContext(WithToken(context.Background(), "tenant"), 0, 0); err != nil {
+		t.Fatalf("ListAddressBooks with tenant token: %v", err)
+	}
+	if _, err := c.ListAddressBooks(0, 0); err != nil {
+		t.Fatalf("ListAddressBooks: %v", err)
+	}
+
+	// An expired override fails instead of refreshing the client's token
+	_, err := c.ListAddressBooksContext(WithToken(context.Background(), "expired"), 0, 0)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("ListAddressBooks with expired token: err = %v, want 401 APIError", err)
+	}
+
+	if want := []string{"Bearer tenant", "Bearer token", "Bearer expired"}; !reflect.DeepEqual(auth, want) {
+		t.Errorf("Authorization headers = %v, want %v", auth, want)
+	}
+	if fetches.Load() != 0 {
+		t.Errorf("fetched %d tokens, want 0", fetches.Load())
+	}
+	if token := c.currentToken(); token != "token" {
+		t.Errorf("client token = %q, want it unchanged", token)
+	}
+	if stored, _ := c.tokenStore.Get(); stored != "" {
+		t.Errorf("stored token = %q, want nothing persisted", stored)
+	}
+}
+
+func TestInitTokenStorageIsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(noRequests(t))
+	defer srv.Close()
+
+	clients := map[string]*Client{
+		"NewClient":        NewClient("user", "secret", file),
+		"FileTokenStorage": NewClientWithOptions("user", "secret", WithTokenStorage(FileTokenStorage{Dir: file})),
+	}
+	for name, c := range clients {
+		t.Run(name, func(t *testing.T) {
+			c.baseURL = srv.URL
+
+			err := c.Init()
+			if !errors.Is(err, ErrTokenStorageNotDir) {
+				t.Fatalf("Init() = %v, want ErrTokenStorageNotDir", err)
+			}
+			if want := "token storage path must be a directory: " + file + " is a file"; err.Error() != want {
+				t.Errorf("Init() = %q, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestGetGroupStatistics(t *testing.T) {
+	campaigns := map[string]Campaign{
+		"/campaigns/1": {ID: 1, GroupID: 4, AllEmailQty: 100, Statistics: []CampaignStatistic{
+			{Code: 1, Count: 90, Explain: "Delivered"},
+			{Code: 3, Count: 40, Explain: "Opened"},
+		}},
+		"/campaigns/3": {ID: 3, GroupID: 4, AllEmailQty: 50, Statistics: []CampaignStatistic{
+			{Code: 1, Count: 45, Explain: "Delivered"},
+			{Code: 2, Count: 5, Explain: "Bounced"},
+		}},
+	}
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/campaigns" {
+			writeJSON(t, w, []Campaign{{ID: 1, GroupID: 4}, {ID: 2, GroupID: 9}, {ID: 3, GroupID: 4}})
+			return
+		}
+		campaign, ok := campaigns[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		writeJSON(t, w, campaign)
+	}))
+
+	stats, err := c.GetGroupStatistics(4)
+	if err != nil {
+		t.Fatalf("GetGroupStatistics: %v", err)
+	}
+
+	want := &GroupStats{
+		GroupID:    4,
+		Campaigns:  2,
+		Sent:       150,
+		Statistics: map[string]int{"Delivered": 135, "Opened": 40, "Bounced": 5},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestPauseBlocksSends(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "POST /smtp/emails":
+			writeJSON(t, w, map[string]interface{}{"result": true, "id": "msg-1"})
+		case "POST /sms/send":
+			writeJSON(t, w, map[string]interface{}{"result": true, "campaign_id": 1})
+		case "POST /campaigns":
+			writeJSON(t, w, Campaign{ID: 11, Status: "0"})
+		case "GET /addressbooks":
+			w.Write([]byte("[]"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	sends := map[string]func() error{
+		"SMTPSend": func() error {
+			_, err := c.SMTPSend(testEmail("to@example.com"))
+			return err
+		},
+		"SMSSend": func() error {
+			return c.SMSSend("Shop", []string{"+49 151 1234567"}, "Hello", nil, false, "")
+		},
+		"CreateCampaign": func() error {
+			_, err := c.CreateCampaign("Shop", "shop@example.com", "Sale", "<p>Sale</p>", 5, "", nil)
+			return err
+		},
+	}
+
+	c.Pause()
+	if !c.Paused() {
+		t.Fatal("Paused() = false after Pause")
+	}
+	for name, send := range sends {
+		if err := send(); !errors.Is(err, ErrClientPaused) {
+			t.Errorf("%s while paused = %v, want ErrClientPaused", name, err)
+		}
+	}
+	if len(requests) != 0 {
+		t.Errorf("paused client sent %v", requests)
+	}
+
+	// Reads are still allowed
+	if _, err := c.ListAddressBooks(0, 0); err != nil {
+		t.Errorf("ListAddressBooks while paused: %v", err)
+	}
+
+	c.Resume()
+	for name, send := range sends {
+		if err := send(); err != nil {
+			t.Errorf("%s after Resume: %v", name, err)
+		}
+	}
+	if len(requests) != 4 {
+		t.Errorf("sent %d requests, want 4: %v", len(requests), requests)
+	}
+}
+
+func TestSMTPListEmailsStatusFilter(t *testing.T) {
+	var queries []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/smtp/emails" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		queries = append(queries, r.URL.RawQuery)
+		writeJSON(t, w, []map[string]interface{}{{"id": "msg-1", "status": "bounced"}})
+	}))
+
+	emails, err := c.SMTPListEmails(10, 0, "2024-03-01", "2024-03-31", "", "", WithEmailStatus(SMTPStatusBounced))
+	if err != nil {
+		t.Fatalf("SMTPListEmails: %v", err)
+	}
+	if len(emails) != 1 {
+		t.Errorf("got %d emails, want 1", len(emails))
+	}
+
+	if _, err := c.SMTPListEmails(10, 0, "", "", "", "", WithEmailStatus("lost")); err == nil {
+		t.Error("SMTPListEmails with unknown status succeeded, want error")
+	}
+
+	if len(queries) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(queries))
+	}
+	q, _ := url.ParseQuery(queries[0])
+	want := url.Values{"limit": {"10"}, "from": {"2024-03-01"}, "to": {"2024-03-31"}, "status": {"bounced"}}
+	if !reflect.DeepEqual(q, want) {
+		t.Errorf("query = %v, want %v", q, want)
+	}
+}
+
+func TestSMTPGetBounceReason(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/smtp/emails/bounced":
+			w.Write([]byte(`{"smtp_answer_code":"550","smtp_answer_subcode":"5.1.1","smtp_answer_code_explain":"Mailbox unavailable","smtp_answer_data":" user unknown "}`))
+		case "/smtp/emails/delivered":
+			w.Write([]byte(`{"smtp_answer_code":250,"smtp_answer_code_explain":"Delivered"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	reason, err := c.SMTPGetBounceReason("bounced")
+	if err != nil {
+		t.Fatalf("SMTPGetBounceReason: %v", err)
+	}
+	if want := "550 5.1.1: user unknown"; reason != want {
+		t.Errorf("reason = %q, want %q", reason, want)
+	}
+
+	reason, err = c.SMTPGetBounceReason("delivered")
+	if err != nil || reason != "" {
+		t.Errorf("SMTPGetBounceReason of a delivered email = %q, %v, want no reason", reason, err)
+	}
+}
+
+// roundTripFunc is an http.RoundTripper implemented by a function
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	var used bool
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		used = true
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	srv := httptest.NewServer(emptyList)
+	defer srv.Close()
+
+	c := NewClientWithOptions("user", "secret", WithHTTPClient(httpClient), WithBaseURL(srv.URL), WithTimeout(5*time.Second))
+	c.Token = "token"
+	if _, err := c.ListAddressBooks(0, 0); err != nil {
+		t.Fatalf("ListAddressBooks: %v", err)
+	}
+	if !used {
+		t.Error("request did not go through the injected client")
+	}
+	if httpClient.Timeout != 0 {
+		t.Errorf("WithTimeout modified the injected client")
+	}
+
+	if timeout := NewClientWithOptions("user", "secret", WithHTTPClient(nil)).httpClient.Timeout; timeout != 30*time.Second {
+		t.Errorf("default timeout = %v, want 30s", timeout)
+	}
+}
+
+func TestPauseBlocksRawRequests(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		writeJSON(t, w, map[string]interface{}{"result": true})
+	}))
+
+	c.Pause()
+	if _, err := c.SendRawRequest("smtp/emails", "POST", map[string]string{"email": "{}"}); !errors.Is(err, ErrClientPaused) {
+		t.Errorf("raw POST while paused = %v, want ErrClientPaused", err)
+	}
+	if _, err := c.SendRawRequest("addressbooks/7", "GET", nil); err != nil {
+		t.Errorf("raw GET while paused: %v", err)
+	}
+
+	c.Resume()
+	if _, err := c.SendRawRequest("smtp/emails", "POST", map[string]string{"email": "{}"}); err != nil {
+		t.Errorf("raw POST after Resume: %v", err)
+	}
+
+	if want := []string{"GET /addressbooks/7", "POST /smtp/emails"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
+
+func TestGetEmailCampaigns(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/emails/ann@example.com/campaigns":
+			w.Write([]byte(`{"statistic":{"sent":"12","open":5,"link":2},"blacklist":0,"last_activity":"2024-03-02 12:30:00"}`))
+		case "/emails/ann@example.com":
+			w.Write([]byte(`[{"book_id":5,"email":"ann@example.com","status":1},{"book_id":8,"email":"ann@example.com","status":4}]`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+		}
+	}))
+
+	stats, err := c.GetEmailCampaigns("ann@example.com")
+	if err != nil {
+		t.Fatalf("GetEmailCampaigns: %v", err)
+	}
+
+	want := &EmailCampaignStats{
+		Sent:         12,
+		Opened:       5,
+		Clicked:      2,
+		LastActivity: time.Date(2024, 3, 2, 12, 30, 0, 0, time.UTC),
+		Books: []EmailBookInfo{
+			{BookID: 5, Email: "ann@example.com", Status: 1},
+			{BookID: 8, Email: "ann@example.com", Status: 4},
+		},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestLoggerRedactsCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/oauth/access_token":
+			writeJSON(t, w, TokenResponse{AccessToken: "fresh-token-xyz", TokenType: "Bearer", ExpiresIn: 3600})
+		case r.Header.Get("Authorization") != "Bearer fresh-token-xyz":
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(t, w, map[string]string{"error": "invalid_token"})
+		case r.URL.Path == "/addressbooks":
+			w.Write([]byte("[]"))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(t, w, map[string]string{"message": "bad request"})
+		}
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewClientWithOptions("user", "client-secret-123",
+		WithBaseURL(srv.URL), WithLogger(logger), WithRetryPolicy(RetryPolicy{}))
+	c.Token = "stale-token-abc"
+
+	// A 401, a token refresh, a success and a failure all get logged
+	if _, err := c.ListAddressBooks(0, 0); err != nil {
+		t.Fatalf("ListAddressBooks: %v", err)
+	}
+	if _, err := c.GetBookInfo(5); err == nil {
+		t.Fatal("GetBookInfo succeeded, want error")
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, "path=addressbooks") || !strings.Contains(out, "request failed") {
+		t.Errorf("logs are missing requests:\n%s", out)
+	}
+	for _, secret := range []string{"stale-token-abc", "fresh-token-xyz", "client-secret-123", "Bearer", "Authorization"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("logs contain %q:\n%s", secret, out)
+		}
+	}
+}
+
+func TestRefreshFailureOn401(t *testing.T) {
+	var fetches, requests atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/access_token" {
+			fetches.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(t, w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(t, w, map[string]string{"error": "invalid_token"})
+	}))
+
+	_, err := c.ListAddressBooks(0, 0)
+	if !errors.Is(err, ErrTokenRefreshFailed) {
+		t.Fatalf("ListAddressBooks = %v, want ErrTokenRefreshFailed", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("err = %v, want it to wrap the token endpoint's 400", err)
+	}
+	if fetches.Load() != 1 || requests.Load() != 1 {
+		t.Errorf("made %d token requests and %d API requests, want 1 each", fetches.Load(), requests.Load())
+	}
+}
+
+func TestPersistent401RefreshesOnce(t *testing.T) {
+	var fetches, requests atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/access_token" {
+			n := fetches.Add(1)
+			writeJSON(t, w, TokenResponse{AccessToken: fmt.Sprintf("fresh-%d", n), TokenType: "Bearer", ExpiresIn: 3600})
+			return
+		}
+		// Even freshly issued tokens are rejected, as with revoked scopes
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(t, w, map[string]string{"error": "invalid_token"})
+	}))
+
+	_, err := c.ListAddressBooks(0, 0)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("ListAddressBooks = %v, want an APIError with status 401", err)
+	}
+	if errors.Is(err, ErrTokenRefreshFailed) {
+		t.Errorf("err = %v, want the 401 rather than a refresh failure", err)
+	}
+	if fetches.Load() != 1 || requests.Load() != 2 {
+		t.Errorf("made %d token requests and %d API requests, want 1 and 2", fetches.Load(), requests.Load())
+	}
+}
+
+func TestAddEmailsSecondChunkFails(t *testing.T) {
+	emails := make([]Email, 5)
+	for i := range emails {
+		emails[i] = Email{Email: fmt.Sprintf("user%d@example.com", i)}
+	}
+
+	var chunks [][]Email
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/addressbooks/5/emails" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var chunk []Email
+		decodeStringField(t, r, "emails", &chunk)
+		chunks = append(chunks, chunk)
+		if len(chunks) == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			writeJSON(t, w, map[string]string{"message": "import failed"})
+			return
+		}
+		writeJSON(t, w, map[string]bool{"result": true})
+	}))
+
+	result, err := c.AddEmails(5, emails, WithChunkSize(2))
+	if err == nil || !strings.Contains(err.Error(), "failed to add emails 2-3") {
+		t.Errorf("AddEmails error = %v, want the second chunk's failure", err)
+	}
+	if want := [][]Email{emails[0:2], emails[2:4], emails[4:5]}; !reflect.DeepEqual(chunks, want) {
+		t.Errorf("chunks = %v, want %v", chunks, want)
+	}
+	if result.Added != 3 || !reflect.DeepEqual(result.Unsent, emails[2:4]) {
+		t.Errorf("added %d, unsent %v; want 3 added and the second chunk unsent", result.Added, result.Unsent)
+	}
+}
+
+func TestSMSAddPhonesSecondChunkFails(t *testing.T) {
+	phones := make([]string, 2*AddPhonesChunkSize+50)
+	for i := range phones {
+		phones[i] = fmt.Sprintf("49151%07d", i)
+	}
+
+	var sizes []int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/sms/numbers" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var chunk []string
+		decodeStringField(t, r, "phones", &chunk)
+		sizes = append(sizes, len(chunk))
+		if len(sizes) == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			writeJSON(t, w, map[string]string{"message": "import failed"})
+			return
+		}
+		writeJSON(t, w, map[string]interface{}{"result": true, "counters": map[string]int{"added": len(chunk)}})
+	}))
+
+	result, err := c.SMSAddPhones(5, phones)
+	if err == nil || !strings.Contains(err.Error(), "failed to add phones 100-199") {
+		t.Errorf("SMSAddPhones error = %v, want the second chunk's failure", err)
+	}
+	if want := []int{100, 100, 50}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("chunk sizes = %v, want %v", sizes, want)
+	}
+	if result.Added != 150 || !reflect.DeepEqual(result.Unsent, phones[100:200]) {
+		t.Errorf("added %d, %d unsent; want 150 added and the second chunk unsent", result.Added, len(result.Unsent))
+	}
+}
+
+func TestGetAllEmailsFromBook(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		pages []string
+	}{
+		{"short last page", 250, []string{"limit=100", "limit=100&offset=100", "limit=100&offset=200"}},
+		// A full last page can't be told apart from a longer book, so one
+		// more, empty page ends the loop
+		{"exact last page", 200, []string{"limit=100", "limit=100&offset=100", "limit=100&offset=200"}},
+		{"empty book", 0, []string{"limit=100"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emails := make([]Email, tt.count)
+			for i := range emails {
+				emails[i] = Email{Email: fmt.Sprintf("user%d@example.com", i)}
+			}
+
+			var pages []string
+			c := newTestClient(t, bookHandler(t, emails, &pages))
+
+			got, err := c.GetAllEmailsFromBook(5)
+			if err != nil {
+				t.Fatalf("GetAllEmailsFromBook: %v", err)
+			}
+			if len(got) != tt.count || (tt.count > 0 && !reflect.DeepEqual(got, emails)) {
+				t.Errorf("got %d emails, want all %d in order", len(got), tt.count)
+			}
+			if !reflect.DeepEqual(pages, tt.pages) {
+				t.Errorf("pages = %v, want %v", pages, tt.pages)
+			}
+		})
+	}
+}
+
+func TestEncodeQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want url.Values
+		ok   bool
+	}{
+		{"ints strings and bools", map[string]interface{}{"limit": 10, "sender": "a@example.com", "validate": true},
+			url.Values{"limit": {"10"}, "sender": {"a@example.com"}, "validate": {"true"}}, true},
+		{"zero values omitted", map[string]interface{}{"limit": 0, "offset": 0, "sender": "", "validate": false, "status": nil},
+			url.Values{}, true},
+		{"explicit zero as string", map[string]interface{}{"offset": "0", "validate": "false"},
+			url.Values{"offset": {"0"}, "validate": {"false"}}, true},
+		{"string map", map[string]string{"from": "2024-03-01", "to": ""},
+			url.Values{"from": {"2024-03-01"}}, true},
+		{"nil", nil, nil, false},
+		{"struct", struct{ Limit int }{10}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, ok := encodeQuery(tt.data)
+			if ok != tt.ok || (ok && !reflect.DeepEqual(query, tt.want)) {
+				t.Errorf("encodeQuery = %v, %v; want %v, %v", query, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestGetParamsInQueryString(t *testing.T) {
+	type request struct {
+		method, query, body string
+	}
+	var requests []request
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{r.Method, r.URL.RawQuery, string(body)})
+		w.Write([]byte("[]"))
+	}))
+
+	if _, err := c.ListCampaigns(10, 20); err != nil {
+		t.Fatalf("ListCampaigns: %v", err)
+	}
+	if _, err := c.SendRawRequest("addressbooks", "GET", map[string]interface{}{"limit": 5, "name": "News letter"}); err != nil {
+		t.Fatalf("raw GET: %v", err)
+	}
+	if _, err := c.SendRawRequest("addressbooks", "POST", map[string]interface{}{"limit": 5}); err != nil {
+		t.Fatalf("raw POST: %v", err)
+	}
+
+	want := []request{
+		{"GET", "limit=10&offset=20", ""},
+		{"GET", "limit=5&name=News+letter", ""},
+		{"POST", "", `{"limit":5}`},
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %+v, want %+v", requests, want)
+	}
+}
+
+func TestGetEmailsCount(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/addressbooks/5/emails/total" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"total":"1234"}`))
+	}))
+
+	count, err := c.GetEmailsCount(5)
+	if err != nil {
+		t.Fatalf("GetEmailsCount: %v", err)
+	}
+	if count != 1234 {
+		t.Errorf("count = %d, want 1234", count)
+	}
+
+	if _, err := c.GetEmailsCount(0); err == nil {
+		t.Error("GetEmailsCount(0) succeeded, want error")
+	}
+}
+
+func TestGetEmailsByVariable(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want := "/addressbooks/5/emails/variable/plan%20name/pro%2Fyearly"; r.Method != "GET" || r.URL.EscapedPath() != want {
+			t.Errorf("unexpected request %s %s, want GET %s", r.Method, r.URL.EscapedPath(), want)
+		}
+		writeJSON(t, w, []Email{{Email: "a@example.com", Variables: map[string]interface{}{"plan name": "pro/yearly"}}})
+	}))
+
+	emails, err := c.GetEmailsByVariable(5, "plan name", "pro/yearly")
+	if err != nil {
+		t.Fatalf("GetEmailsByVariable: %v", err)
+	}
+	if len(emails) != 1 || emails[0].Email != "a@example.com" {
+		t.Errorf("emails = %+v", emails)
+	}
+
+	if _, err := c.GetEmailsByVariable(5, "", "pro"); err == nil {
+		t.Error("GetEmailsByVariable with empty variable succeeded, want error")
+	}
+}
+
+func TestUnsubscribeAndActivateEmails(t *testing.T) {
+	type request struct {
+		method string
+		emails []string
+	}
+	var requests []request
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/addressbooks/5/emails/unsubscribe" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var emails []string
+		decodeStringField(t, r, "emails", &emails)
+		requests = append(requests, request{r.Method, emails})
+		writeJSON(t, w, map[string]bool{"result": true})
+	}))
+
+	if err := c.UnsubscribeEmails(5, []string{"a@example.com", "b@example.com"}); err != nil {
+		t.Fatalf("UnsubscribeEmails: %v", err)
+	}
+	if err := c.ActivateEmails(5, []string{"a@example.com"}); err != nil {
+		t.Fatalf("ActivateEmails: %v", err)
+	}
+
+	want := []request{
+		{"POST", []string{"a@example.com", "b@example.com"}},
+		{"DELETE", []string{"a@example.com"}},
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %+v, want %+v", requests, want)
+	}
+
+	if err := c.UnsubscribeEmails(5, nil); err == nil {
+		t.Error("UnsubscribeEmails with no emails succeeded, want error")
+	}
+	if err := c.ActivateEmails(0, []string{"a@example.com"}); err == nil {
+		t.Error("ActivateEmails with empty book id succeeded, want error")
+	}
+}
+
+func TestListSMSCampaigns(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/sms/campaigns/list" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("dateFrom") != "2024-03-01 00:00:00" || query.Get("dateTo") != "2024-03-31 23:59:59" {
+			t.Errorf("query = %s, want the date range", r.URL.RawQuery)
+		}
+		w.Write([]byte(`[
+			{"id": 1, "sender": "Shop", "body": "Sale", "status": "sent", "send_date": "2024-03-02 10:00:00", "all_recipients": "12"},
+			{"id": 2, "sender": "Shop", "body": "Reminder", "status": "scheduled", "all_recipients": 3}
+		]`))
+	}))
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC)
+	campaigns, err := c.ListSMSCampaigns(from, to)
+	if err != nil {
+		t.Fatalf("ListSMSCampaigns: %v", err)
+	}
+	if len(campaigns) != 2 {
+		t.Fatalf("got %d campaigns, want 2", len(campaigns))
+	}
+	if campaigns[0].Recipients != 12 || !campaigns[0].SendDate.Equal(time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("campaign = %+v, want 12 recipients sent on 2024-03-02", campaigns[0])
+	}
+	if campaigns[1].Status != "scheduled" || !campaigns[1].SendDate.IsZero() {
+		t.Errorf("campaign = %+v, want scheduled without a send date", campaigns[1])
+	}
+
+	if _, err := c.ListSMSCampaigns(to, from); err == nil {
+		t.Error("ListSMSCampaigns with a reversed range succeeded, want error")
+	}
+}
+
+func TestGetSMSCampaignInfo(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/sms/campaigns/info/7" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"result": true, "data": {"id": 7, "sender": "Shop", "body": "Sale", "status": "sent", "all_recipients": 2}}`))
+	}))
+
+	campaign, err := c.GetSMSCampaignInfo(7)
+	if err != nil {
+		t.Fatalf("GetSMSCampaignInfo: %v", err)
+	}
+	want := SMSCampaign{ID: 7, Sender: "Shop", Body: "Sale", Status: "sent", Recipients: 2}
+	if !reflect.DeepEqual(*campaign, want) {
+		t.Errorf("campaign = %+v, want %+v", *campaign, want)
+	}
+
+	if _, err := c.GetSMSCampaignInfo(0); err == nil {
+		t.Error("GetSMSCampaignInfo with an empty id succeeded, want error")
+	}
+}
+
+func TestCancelAndDeleteSMSCampaign(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "DELETE" {
+			var data map[string]int
+			readJSON(t, r, &data)
+			if data["id"] != 7 {
+				t.Errorf("delete body = %v, want id 7", data)
+			}
+		}
+		writeJSON(t, w, map[string]bool{"result": true})
+	}))
+
+	if err := c.CancelSMSCampaign(7); err != nil {
+		t.Fatalf("CancelSMSCampaign: %v", err)
+	}
+	if err := c.DeleteSMSCampaign(7); err != nil {
+		t.Fatalf("DeleteSMSCampaign: %v", err)
+	}
+	want := []string{"PUT /sms/campaigns/cancel/7", "DELETE /sms/campaigns"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+
+	if err := c.CancelSMSCampaign(0); err == nil {
+		t.Error("CancelSMSCampaign with an empty id succeeded, want error")
+	}
+	if err := c.DeleteSMSCampaign(0); err == nil {
+		t.Error("DeleteSMSCampaign with an empty id succeeded, want error")
+	}
+	if len(requests) != 2 {
+		t.Errorf("sent %d requests, want none for empty ids", len(requests)-2)
+	}
+}
+
+// smsCampaignPhonesHandler serves the per-phone delivery information of SMS
+// campaign 7
+func smsCampaignPhonesHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/sms/campaigns/info/7" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"result": true, "data": {"id": 7, "task_phones_info": [
+			{"phone": "380501112233", "status": "delivered", "updated": "2024-03-02 10:05:00"},
+			{"phone": "380501112234", "status": "failed", "updated": "2024-03-02 10:06:00"},
+			{"phone": "380501112235", "status": "sent"},
+			{"phone": "380501112236", "status": "pending"}
+		]}}`))
+	})
+}
+
+func TestGetSMSCampaignStats(t *testing.T) {
+	c := newTestClient(t, smsCampaignPhonesHandler(t))
+
+	stats, err := c.GetSMSCampaignStats(7)
+	if err != nil {
+		t.Fatalf("GetSMSCampaignStats: %v", err)
+	}
+	// Sent but unconfirmed phones count as pending
+	want := SMSStats{CampaignID: 7, Total: 4, Delivered: 1, Failed: 1, Pending: 2}
+	if *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+
+	if _, err := c.GetSMSCampaignStats(0); err == nil {
+		t.Error("GetSMSCampaignStats with an empty id succeeded, want error")
+	}
+}
+
+func TestGetSMSPhoneInfo(t *testing.T) {
+	c := newTestClient(t, smsCampaignPhonesHandler(t))
+
+	// The phone matches regardless of formatting
+	status, err := c.GetSMSPhoneInfo(7, "+380 (50) 111-22-34")
+	if err != nil {
+		t.Fatalf("GetSMSPhoneInfo: %v", err)
+	}
+	want := SMSStatus{Phone: "380501112234", Status: SMSStatusFailed, UpdatedAt: time.Date(2024, 3, 2, 10, 6, 0, 0, time.UTC)}
+	if *status != want {
+		t.Errorf("status = %+v, want %+v", *status, want)
+	}
+
+	if _, err := c.GetSMSPhoneInfo(7, "380509999999"); err == nil {
+		t.Error("GetSMSPhoneInfo of a phone outside the campaign succeeded, want error")
+	}
+	if _, err := c.GetSMSPhoneInfo(7, ""); err == nil {
+		t.Error("GetSMSPhoneInfo with an empty phone succeeded, want error")
+	}
+}
+
+func TestAddressBookEmailCounts(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/addressbooks/5":
+			w.Write([]byte(`{"id": 5, "name": "Customers", "all_email_qty": 10, "active_email_qty": 7, "inactive_email_qty": 3}`))
+		case "/addressbooks":
+			// The counts are left out of the list
+			w.Write([]byte(`[{"id": 5, "name": "Customers"}]`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	book, err := c.GetBookInfo(5)
+	if err != nil {
+		t.Fatalf("GetBookInfo: %v", err)
+	}
+	want := AddressBook{ID: 5, Name: "Customers", AllEmailQty: 10, ActiveEmailQty: 7, InactiveEmailQty: 3}
+	if *book != want {
+		t.Errorf("book = %+v, want %+v", *book, want)
+	}
+
+	books, err := c.ListAddressBooks(0, 0)
+	if err != nil {
+		t.Fatalf("ListAddressBooks: %v", err)
+	}
+	if want := []AddressBook{{ID: 5, Name: "Customers"}}; !reflect.DeepEqual(books, want) {
+		t.Errorf("books = %+v, want %+v", books, want)
+	}
+}
+
+func TestSMSRemovePhones(t *testing.T) {
+	var requests int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != "DELETE" || r.URL.Path != "/sms/numbers" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var data struct {
+			AddressBookID int    `json:"addressBookId"`
+			Phones        string `json:"phones"`
+		}
+		readJSON(t, r, &data)
+		var phones []string
+		if err := json.Unmarshal([]byte(data.Phones), &phones); err != nil {
+			t.Errorf("phones %q is not a JSON array: %v", data.Phones, err)
+		}
+		if data.AddressBookID != 5 || !reflect.DeepEqual(phones, []string{"380501112233", "380501112234"}) {
+			t.Errorf("body = %+v, want book 5 and both phones", data)
+		}
+		writeJSON(t, w, map[string]bool{"result": true})
+	}))
+
+	if err := c.SMSRemovePhones(5, []string{"380501112233", "380501112234"}); err != nil {
+		t.Fatalf("SMSRemovePhones: %v", err)
+	}
+
+	if err := c.SMSRemovePhones(5, nil); err == nil {
+		t.Error("SMSRemovePhones with no phones succeeded, want error")
+	}
+	if err := c.SMSRemovePhones(0, []string{"380501112233"}); err == nil {
+		t.Error("SMSRemovePhones with an empty book id succeeded, want error")
+	}
+	if requests != 1 {
+		t.Errorf("sent %d requests, want 1", requests)
+	}
+}
+
+func TestSMSGetPhoneInfo(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/sms/numbers/info/5/380501112233" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"result": true, "data": {"phone": "380501112233", "variables": {"name": "Ann"}}}`))
+	}))
+
+	phone, err := c.SMSGetPhoneInfo(5, "380501112233")
+	if err != nil {
+		t.Fatalf("SMSGetPhoneInfo: %v", err)
+	}
+	want := Phone{Phone: "380501112233", Variables: map[string]interface{}{"name": "Ann"}}
+	if !reflect.DeepEqual(*phone, want) {
+		t.Errorf("phone = %+v, want %+v", *phone, want)
+	}
+
+	if _, err := c.SMSGetPhoneInfo(5, ""); err == nil {
+		t.Error("SMSGetPhoneInfo with an empty phone succeeded, want error")
+	}
+}
+
+func TestSMTPSendMailReturnsResultWithoutPrinting(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/smtp/emails" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var email map[string]interface{}
+		decodeStringField(t, r, "email", &email)
+		if html, _ := base64.StdEncoding.DecodeString(fmt.Sprint(email["html"])); string(html) != "<p>Hello</p>" {
+			t.Errorf("html = %v, want base64 of <p>Hello</p>", email["html"])
+		}
+		writeJSON(t, w, map[string]interface{}{"result": true, "id": "msg-1"})
+	}))
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	result, sendErr := c.SMTPSendMail(map[string]interface{}{"subject": "Hello", "html": "<p>Hello</p>"})
+	os.Stdout = stdout
+	w.Close()
+	printed, _ := io.ReadAll(r)
+
+	if sendErr != nil {
+		t.Fatalf("SMTPSendMail: %v", sendErr)
+	}
+	if !result.Result || result.ID != "msg-1" {
+		t.Errorf("result = %+v, want the parsed send result", *result)
+	}
+	if len(printed) != 0 {
+		t.Errorf("SMTPSendMail printed %q, want no output", printed)
+	}
+}
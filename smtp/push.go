@@ -0,0 +1,132 @@
+package smtp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PushWebsite represents a website registered for web push notifications
+type PushWebsite struct {
+	ID      int      `json:"id"`
+	URL     string   `json:"url"`
+	AddDate FlexTime `json:"add_date"`
+	Status  FlexInt  `json:"status"`
+}
+
+// PushCampaign represents a web push notification to send to the
+// subscribers of a website. TTL is how long the notification stays
+// deliverable; a zero SendDate sends it immediately.
+type PushCampaign struct {
+	WebsiteID int
+	Title     string
+	Body      string
+	Link      string
+	TTL       time.Duration
+	SendDate  time.Time
+}
+
+// PushCampaignStats represents the delivery statistics of a web push campaign
+type PushCampaignStats struct {
+	ID        int      `json:"id"`
+	Status    FlexInt  `json:"status"`
+	SendDate  FlexTime `json:"send_date"`
+	Sent      FlexInt  `json:"send"`
+	Delivered FlexInt  `json:"delivered"`
+	Clicked   FlexInt  `json:"redirect"`
+}
+
+// ListPushWebsites retrieves the websites registered for web push
+func (c *Client) ListPushWebsites() ([]PushWebsite, error) {
+	return c.ListPushWebsitesContext(context.Background())
+}
+
+// ListPushWebsitesContext is like ListPushWebsites but uses ctx for cancellation and deadlines
+func (c *Client) ListPushWebsitesContext(ctx context.Context) ([]PushWebsite, error) {
+	resp, header, err := c.doRequest(ctx, "push/websites", "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var websites []PushWebsite
+	if _, err := decodeList(resp, header, &websites); err != nil {
+		return nil, fmt.Errorf("failed to parse push websites: %w", err)
+	}
+
+	return websites, nil
+}
+
+// CreatePushCampaign creates a web push campaign and returns its id
+func (c *Client) CreatePushCampaign(campaign PushCampaign) (int, error) {
+	return c.CreatePushCampaignContext(context.Background(), campaign)
+}
+
+// CreatePushCampaignContext is like CreatePushCampaign but uses ctx for cancellation and deadlines
+func (c *Client) CreatePushCampaignContext(ctx context.Context, campaign PushCampaign) (int, error) {
+	if c.paused.Load() {
+		return 0, ErrClientPaused
+	}
+
+	if campaign.WebsiteID == 0 {
+		return 0, fmt.Errorf("empty website id")
+	}
+	if campaign.Title == "" || campaign.Body == "" {
+		return 0, fmt.Errorf("empty push title or body")
+	}
+
+	data := map[string]interface{}{
+		"website_id": campaign.WebsiteID,
+		"title":      campaign.Title,
+		"body":       campaign.Body,
+		"ttl":        int(campaign.TTL.Seconds()),
+	}
+	if campaign.Link != "" {
+		data["link"] = campaign.Link
+	}
+	if !campaign.SendDate.IsZero() {
+		data["send_date"] = campaign.SendDate.UTC().Format("2006-01-02 15:04:05")
+	}
+
+	resp, err := c.sendRequest(ctx, "push/tasks", "POST", data, true)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		Result bool    `json:"result"`
+		ID     FlexInt `json:"id"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse push campaign response: %w", err)
+	}
+	if !result.Result || result.ID == 0 {
+		return 0, fmt.Errorf("push campaign was not created")
+	}
+
+	return int(result.ID), nil
+}
+
+// GetPushCampaignStats retrieves delivery statistics for a web push campaign
+func (c *Client) GetPushCampaignStats(id int) (*PushCampaignStats, error) {
+	return c.GetPushCampaignStatsContext(context.Background(), id)
+}
+
+// GetPushCampaignStatsContext is like GetPushCampaignStats but uses ctx for cancellation and deadlines
+func (c *Client) GetPushCampaignStatsContext(ctx context.Context, id int) (*PushCampaignStats, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("empty push campaign id")
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("push/tasks/%d", id), "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats PushCampaignStats
+	if err := json.Unmarshal(resp, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse push campaign stats: %w", err)
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,84 @@
+package smtp
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestListPushWebsites(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/push/websites" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`[{"id":3,"url":"example.com","add_date":"2024-01-02 10:00:00","status":"1"}]`))
+	}))
+
+	sites, err := c.ListPushWebsites()
+	if err != nil {
+		t.Fatalf("ListPushWebsites: %v", err)
+	}
+	if len(sites) != 1 || sites[0].ID != 3 || sites[0].Status != 1 || sites[0].AddDate.Year() != 2024 {
+		t.Errorf("sites = %+v", sites)
+	}
+}
+
+func TestCreatePushCampaign(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/push/tasks" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		var data map[string]interface{}
+		readJSON(t, r, &data)
+		if data["website_id"] != 3.0 || data["title"] != "Sale" || data["ttl"] != 3600.0 || data["link"] != "https://example.com" {
+			t.Errorf("unexpected payload %v", data)
+		}
+		if _, ok := data["send_date"]; ok {
+			t.Errorf("send_date sent for an immediate campaign")
+		}
+		writeJSON(t, w, map[string]interface{}{"result": true, "id": 42})
+	}))
+
+	id, err := c.CreatePushCampaign(PushCampaign{
+		WebsiteID: 3,
+		Title:     "Sale",
+		Body:      "50% off",
+		Link:      "https://example.com",
+		TTL:       time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("CreatePushCampaign: %v", err)
+	}
+	if id != 42 {
+		t.Errorf("id = %d, want 42", id)
+	}
+}
+
+func TestCreatePushCampaignPaused(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("paused client sent %s %s", r.Method, r.URL.Path)
+	}))
+	c.Pause()
+
+	if _, err := c.CreatePushCampaign(PushCampaign{WebsiteID: 3, Title: "Sale", Body: "50% off"}); err != ErrClientPaused {
+		t.Fatalf("CreatePushCampaign error = %v, want ErrClientPaused", err)
+	}
+}
+
+func TestGetPushCampaignStats(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/push/tasks/42" {
+			t.Errorf("path = %q, want /push/tasks/42", r.URL.Path)
+		}
+		w.Write([]byte(`{"id":42,"status":3,"send":"100","delivered":90,"redirect":"7"}`))
+	}))
+
+	stats, err := c.GetPushCampaignStats(42)
+	if err != nil {
+		t.Fatalf("GetPushCampaignStats: %v", err)
+	}
+	if stats.Sent != 100 || stats.Delivered != 90 || stats.Clicked != 7 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
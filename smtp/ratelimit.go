@@ -0,0 +1,68 @@
+package smtp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket allowing rate requests per second with bursts
+// of up to burst requests
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// now and sleep are time.Now and sleepContext outside of tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// newRateLimiter creates a limiter that starts with a full bucket
+func newRateLimiter(rps, burst int) *rateLimiter {
+	return newRateLimiterWithClock(rps, burst, time.Now, sleepContext)
+}
+
+// newRateLimiterWithClock is like newRateLimiter but reads the time from now
+// and waits with sleep
+func newRateLimiterWithClock(rps, burst int, now func() time.Time, sleep func(context.Context, time.Duration) error) *rateLimiter {
+	return &rateLimiter{
+		rate:   float64(rps),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now(),
+		now:    now,
+		sleep:  sleep,
+	}
+}
+
+// wait blocks until a request may be sent or ctx is cancelled
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		delay := l.reserve(l.now())
+		if delay <= 0 {
+			return nil
+		}
+		if err := l.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes a token if one is available at now, otherwise it returns how
+// long until the next token is added
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
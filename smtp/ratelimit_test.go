@@ -0,0 +1,105 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newFakeLimiter returns a limiter on a fake clock that only moves when the
+// limiter sleeps, recording each sleep
+func newFakeLimiter(rps, burst int, slept *[]time.Duration) (*rateLimiter, *time.Time) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiterWithClock(rps, burst, func() time.Time { return now }, func(ctx context.Context, d time.Duration) error {
+		*slept = append(*slept, d)
+		now = now.Add(d)
+		return ctx.Err()
+	})
+	return l, &now
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	var slept []time.Duration
+	l, _ := newFakeLimiter(2, 3, &slept)
+
+	for range 3 {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	}
+	if len(slept) != 0 {
+		t.Errorf("slept %v within the burst, want no waits", slept)
+	}
+
+	// The bucket is empty, so the next request waits for one token at 2 rps
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if want := []time.Duration{500 * time.Millisecond}; !reflect.DeepEqual(slept, want) {
+		t.Errorf("slept %v, want %v", slept, want)
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	var slept []time.Duration
+	l, now := newFakeLimiter(10, 5, &slept)
+
+	for range 5 {
+		l.wait(context.Background())
+	}
+
+	// A second refills 10 tokens but the bucket holds only 5
+	*now = now.Add(time.Second)
+	for range 5 {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	}
+	if len(slept) != 0 {
+		t.Errorf("slept %v after refilling, want no waits", slept)
+	}
+
+	l.wait(context.Background())
+	if want := []time.Duration{100 * time.Millisecond}; !reflect.DeepEqual(slept, want) {
+		t.Errorf("slept %v, want %v", slept, want)
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	var slept []time.Duration
+	l, _ := newFakeLimiter(1, 1, &slept)
+	l.wait(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait = %v, want context.Canceled", err)
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	c := newTestClient(t, emptyList)
+
+	c.SetRateLimit(5, 0)
+	l := c.limiter.Load()
+	if l == nil || l.rate != 5 || l.burst != 1 {
+		t.Fatalf("limiter = %+v, want 5 rps with a burst of 1", l)
+	}
+
+	// A cancelled context fails the request while it waits for a token
+	if _, err := c.ListAddressBooks(0, 0); err != nil {
+		t.Fatalf("ListAddressBooks: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ListAddressBooksContext(ctx, 0, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListAddressBooks = %v, want context.Canceled", err)
+	}
+
+	c.SetRateLimit(0, 0)
+	if c.limiter.Load() != nil {
+		t.Error("SetRateLimit(0, 0) kept the limiter")
+	}
+}
@@ -0,0 +1,81 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how transient request failures are retried. Network
+// errors and 5xx responses are retried for idempotent methods; 429 responses
+// are retried for every method, honoring any Retry-After header.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used by clients created with NewClient
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+}
+
+// backoff returns the delay before retry number attempt (starting at 0),
+// doubling from BaseDelay up to MaxDelay with jitter
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// Pick a delay in [delay/2, delay) so concurrent callers spread out
+	half := delay / 2
+	return half + rand.N(delay-half)
+}
+
+// isIdempotent reports whether requests with method can be safely repeated
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// isTransportError reports whether err is a network failure rather than a
+// problem building the request or a cancelled context
+func isTransportError(ctx context.Context, err error) bool {
+	var urlErr *url.Error
+	return ctx.Err() == nil && errors.As(err, &urlErr)
+}
+
+// isRetryableStatus reports whether a response status is worth retrying
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,183 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffBounds(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{70, time.Second}, // the shift overflows
+	}
+	for _, tt := range tests {
+		for range 100 {
+			if d := policy.backoff(tt.attempt); d < tt.max/2 || d >= tt.max {
+				t.Fatalf("backoff(%d) = %v, want in [%v, %v)", tt.attempt, d, tt.max/2, tt.max)
+			}
+		}
+	}
+
+	if d := (RetryPolicy{}).backoff(2); d != 0 {
+		t.Errorf("backoff of a zero policy = %v, want 0", d)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}
+
+	seen := make(map[time.Duration]bool)
+	for range 20 {
+		seen[policy.backoff(0)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("backoff returned the same delay 20 times, want jitter")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+		ok    bool
+	}{
+		{"empty", "", 0, 0, false},
+		{"seconds", "5", 5 * time.Second, 5 * time.Second, true},
+		{"zero seconds", "0", 0, 0, true},
+		{"negative seconds", "-1", 0, 0, false},
+		{"garbage", "soon", 0, 0, false},
+		{"future date", time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second, true},
+		{"past date", "Wed, 21 Oct 2015 07:28:00 GMT", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := parseRetryAfter(tt.value)
+			if ok != tt.ok || d < tt.min || d > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %v, %v; want [%v, %v], %v", tt.value, d, ok, tt.min, tt.max, tt.ok)
+			}
+		})
+	}
+}
+
+// countingHandler counts requests and answers each with the status returned
+// by status for that attempt, starting at 1
+func countingHandler(t *testing.T, attempts *atomic.Int32, status func(attempt int32) int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := status(attempts.Add(1))
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(code)
+		writeJSON(t, w, map[string]bool{"result": code == http.StatusOK})
+	})
+}
+
+func TestRetries(t *testing.T) {
+	always := func(code int) func(int32) int {
+		return func(int32) int { return code }
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		status   func(attempt int32) int
+		attempts int32
+		wantErr  int
+	}{
+		{"GET 503 exhausts retries", "GET", always(http.StatusServiceUnavailable), 3, http.StatusServiceUnavailable},
+		{"GET 503 then success", "GET", func(n int32) int {
+			if n == 1 {
+				return http.StatusServiceUnavailable
+			}
+			return http.StatusOK
+		}, 2, 0},
+		{"POST 503 not retried", "POST", always(http.StatusServiceUnavailable), 1, http.StatusServiceUnavailable},
+		{"POST 429 retried", "POST", always(http.StatusTooManyRequests), 3, http.StatusTooManyRequests},
+		{"DELETE 502 retried", "DELETE", always(http.StatusBadGateway), 3, http.StatusBadGateway},
+		{"GET 400 not retried", "GET", always(http.StatusBadRequest), 1, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			c := newTestClient(t, countingHandler(t, &attempts, tt.status))
+			c.RetryPolicy = RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+			_, err := c.SendRawRequest("addressbooks", tt.method, nil)
+			var apiErr *APIError
+			switch {
+			case tt.wantErr == 0 && err != nil:
+				t.Errorf("request failed: %v", err)
+			case tt.wantErr != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantErr):
+				t.Errorf("err = %v, want APIError with status %d", err, tt.wantErr)
+			}
+			if attempts.Load() != tt.attempts {
+				t.Errorf("sent %d attempts, want %d", attempts.Load(), tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetriesNetworkErrors(t *testing.T) {
+	// Hijacking and closing the connection makes every request fail in transport
+	hangUp := func(attempts *atomic.Int32) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Close()
+		})
+	}
+
+	for method, want := range map[string]int32{"GET": 3, "POST": 1} {
+		var attempts atomic.Int32
+		c := newTestClient(t, hangUp(&attempts))
+		c.RetryPolicy = RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+		if _, err := c.SendRawRequest("addressbooks", method, nil); err == nil {
+			t.Errorf("%s succeeded, want a network error", method)
+		}
+		if attempts.Load() != want {
+			t.Errorf("%s sent %d attempts, want %d", method, attempts.Load(), want)
+		}
+	}
+}
+
+func TestRetryBackoffCancelled(t *testing.T) {
+	var attempts atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	c.RetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.SendRawRequestContext(ctx, "addressbooks", "GET", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request returned after %v, want the backoff cut short", elapsed)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("sent %d attempts, want 1", attempts.Load())
+	}
+}
@@ -0,0 +1,88 @@
+package smtp
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSenderManagement(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /senders":
+			w.Write([]byte(`[{"name":"Shop","email":"shop@example.com","status":"Active"}]`))
+		case "POST /senders":
+			var data map[string]string
+			readJSON(t, r, &data)
+			if data["name"] != "News" || data["email"] != "news@example.com" {
+				t.Errorf("sender data = %v", data)
+			}
+			writeJSON(t, w, map[string]bool{"result": true})
+		case "POST /senders/news@example.com/code":
+			var data map[string]string
+			readJSON(t, r, &data)
+			if data["code"] != "123456" {
+				t.Errorf("activation code = %q, want 123456", data["code"])
+			}
+			writeJSON(t, w, map[string]bool{"result": true})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	senders, err := c.ListSenders()
+	if err != nil {
+		t.Fatalf("ListSenders: %v", err)
+	}
+	if want := []Sender{{Name: "Shop", Email: "shop@example.com", Status: SenderActive}}; !reflect.DeepEqual(senders, want) {
+		t.Errorf("senders = %+v, want %+v", senders, want)
+	}
+
+	if err := c.AddSender("News", "news@example.com"); err != nil {
+		t.Fatalf("AddSender: %v", err)
+	}
+	if err := c.ActivateSender("news@example.com", "123456"); err != nil {
+		t.Fatalf("ActivateSender: %v", err)
+	}
+
+	if err := c.AddSender("", "news@example.com"); err == nil {
+		t.Error("AddSender with empty name succeeded, want error")
+	}
+	if err := c.ActivateSender("news@example.com", ""); err == nil {
+		t.Error("ActivateSender with empty code succeeded, want error")
+	}
+
+	want := []string{"GET /senders", "POST /senders", "POST /senders/news@example.com/code"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
+
+func TestGetSendersVerification(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/senders" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`[
+			{"name":"Shop","email":"shop@example.com","status":"Active"},
+			{"name":"News","email":"news@example.com","status":"Inactive"}
+		]`))
+	}))
+
+	senders, err := c.GetSenders()
+	if err != nil {
+		t.Fatalf("GetSenders: %v", err)
+	}
+
+	var verified []string
+	for _, sender := range senders {
+		if sender.Verified() {
+			verified = append(verified, sender.Email)
+		}
+	}
+	if len(senders) != 2 || !reflect.DeepEqual(verified, []string{"shop@example.com"}) {
+		t.Errorf("got %d senders with %v verified, want 2 with only shop@example.com", len(senders), verified)
+	}
+}
@@ -0,0 +1,120 @@
+package smtp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Template represents a stored email template. Body is returned exactly as
+// the API sends it; use DecodedBody for a body known to be base64-encoded.
+type Template struct {
+	ID   FlexInt `json:"real_id"`
+	Name string  `json:"name"`
+	Body string  `json:"body"`
+	Lang string  `json:"lang"`
+}
+
+// DecodedBody returns the template body decoded from base64, the encoding
+// CreateTemplate uses to upload it. A plain body can itself be valid base64,
+// so it is never decoded implicitly.
+func (t Template) DecodedBody() (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(t.Body)
+	if err != nil {
+		return "", fmt.Errorf("template body is not base64: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// ListTemplates retrieves the account's email templates
+func (c *Client) ListTemplates() ([]Template, error) {
+	return c.ListTemplatesContext(context.Background())
+}
+
+// ListTemplatesContext is like ListTemplates but uses ctx for cancellation and deadlines
+func (c *Client) ListTemplatesContext(ctx context.Context) ([]Template, error) {
+	resp, err := c.sendRequest(ctx, "templates", "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []Template
+	if err := json.Unmarshal(resp, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// GetTemplate retrieves an email template
+func (c *Client) GetTemplate(id int) (*Template, error) {
+	return c.GetTemplateContext(context.Background(), id)
+}
+
+// GetTemplateContext is like GetTemplate but uses ctx for cancellation and deadlines
+func (c *Client) GetTemplateContext(ctx context.Context, id int) (*Template, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("empty template id")
+	}
+
+	resp, err := c.sendRequest(ctx, fmt.Sprintf("template/%d", id), "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var template Template
+	if err := json.Unmarshal(resp, &template); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &template, nil
+}
+
+// CreateTemplate stores an email template and returns its ID. lang is a two
+// letter language code such as "en".
+func (c *Client) CreateTemplate(name, body, lang string) (int, error) {
+	return c.CreateTemplateContext(context.Background(), name, body, lang)
+}
+
+// CreateTemplateContext is like CreateTemplate but uses ctx for cancellation and deadlines
+func (c *Client) CreateTemplateContext(ctx context.Context, name, body, lang string) (int, error) {
+	if name == "" || body == "" {
+		return 0, fmt.Errorf("empty template name or body")
+	}
+
+	data := map[string]string{
+		"name": name,
+		"body": base64.StdEncoding.EncodeToString([]byte(body)),
+		"lang": lang,
+	}
+
+	resp, err := c.sendRequest(ctx, "template", "POST", data, true)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		ID FlexInt `json:"real_id"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return int(result.ID), nil
+}
+
+// DeleteTemplate removes an email template
+func (c *Client) DeleteTemplate(id int) error {
+	return c.DeleteTemplateContext(context.Background(), id)
+}
+
+// DeleteTemplateContext is like DeleteTemplate but uses ctx for cancellation and deadlines
+func (c *Client) DeleteTemplateContext(ctx context.Context, id int) error {
+	if id == 0 {
+		return fmt.Errorf("empty template id")
+	}
+
+	_, err := c.sendRequest(ctx, fmt.Sprintf("template/%d", id), "DELETE", nil, true)
+	return err
+}
@@ -0,0 +1,70 @@
+package smtp
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+)
+
+func TestGetTemplateKeepsPlainBody(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/template/3":
+			// "test" is plain text that also happens to be valid base64
+			writeJSON(t, w, map[string]interface{}{"real_id": 3, "name": "Plain", "body": "test", "lang": "en"})
+		case "/templates":
+			writeJSON(t, w, []map[string]interface{}{{"real_id": 3, "name": "Plain", "body": "abcd", "lang": "en"}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	template, err := c.GetTemplate(3)
+	if err != nil {
+		t.Fatalf("GetTemplate: %v", err)
+	}
+	if template.Body != "test" {
+		t.Errorf("body = %q, want it unchanged", template.Body)
+	}
+
+	templates, err := c.ListTemplates()
+	if err != nil {
+		t.Fatalf("ListTemplates: %v", err)
+	}
+	if len(templates) != 1 || templates[0].Body != "abcd" {
+		t.Errorf("templates = %+v, want the body unchanged", templates)
+	}
+}
+
+func TestTemplateDecodedBody(t *testing.T) {
+	encoded := Template{Body: base64.StdEncoding.EncodeToString([]byte("<p>Hello</p>"))}
+	if body, err := encoded.DecodedBody(); err != nil || body != "<p>Hello</p>" {
+		t.Errorf("DecodedBody() = %q, %v; want <p>Hello</p>", body, err)
+	}
+
+	if _, err := (Template{Body: "<p>Hello</p>"}).DecodedBody(); err == nil {
+		t.Error("DecodedBody() of a plain HTML body succeeded, want error")
+	}
+}
+
+func TestCreateTemplateEncodesBody(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/template" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var data map[string]string
+		readJSON(t, r, &data)
+		if body, _ := base64.StdEncoding.DecodeString(data["body"]); string(body) != "<p>Hello</p>" {
+			t.Errorf("body = %q, want base64 of <p>Hello</p>", data["body"])
+		}
+		writeJSON(t, w, map[string]interface{}{"real_id": "9"})
+	}))
+
+	id, err := c.CreateTemplate("Welcome", "<p>Hello</p>", "en")
+	if err != nil {
+		t.Fatalf("CreateTemplate: %v", err)
+	}
+	if id != 9 {
+		t.Errorf("id = %d, want 9", id)
+	}
+}
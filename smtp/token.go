@@ -0,0 +1,160 @@
+package smtp
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrTokenStorageNotDir is returned when the token storage path exists but
+// is a file rather than a directory
+var ErrTokenStorageNotDir = errors.New("token storage path must be a directory")
+
+// checkTokenDir returns ErrTokenStorageNotDir if dir exists and is not a directory
+func checkTokenDir(dir string) error {
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		return fmt.Errorf("%w: %s is a file", ErrTokenStorageNotDir, dir)
+	}
+	return nil
+}
+
+// tokenDirStore is implemented by token stores that keep tokens in files
+// under a directory, which Validate checks is writable
+type tokenDirStore interface {
+	tokenDir() string
+}
+
+// TokenStore persists the client's access token between runs
+type TokenStore interface {
+	// Get returns the stored token, or an empty string if none is stored
+	Get() (string, error)
+	// Set stores the token, replacing any previous one
+	Set(token string) error
+}
+
+// TokenStorage persists access tokens for any number of accounts. Each client
+// uses the hex MD5 of UserID + "::" + Secret as its key, the same name
+// FileTokenStore gives its file, so one storage can serve several accounts.
+// Implement it to keep tokens in Redis, a secrets manager or anywhere else
+// shared between processes; WithTokenStorage adapts it to a TokenStore.
+type TokenStorage interface {
+	// Load returns the token stored under key, or an empty string if none is
+	Load(key string) (string, error)
+	// Save stores token under key, replacing any previous one
+	Save(key, token string) error
+}
+
+// tokenKey returns the storage key for a client's credentials: the hex MD5
+// of userID + "::" + secret
+func tokenKey(userID, secret string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(userID+"::"+secret)))
+}
+
+// keyedTokenStore adapts a TokenStorage to the TokenStore of one client
+type keyedTokenStore struct {
+	storage TokenStorage
+	key     string
+}
+
+// Get implements TokenStore
+func (s *keyedTokenStore) Get() (string, error) {
+	return s.storage.Load(s.key)
+}
+
+// Set implements TokenStore
+func (s *keyedTokenStore) Set(token string) error {
+	return s.storage.Save(s.key, token)
+}
+
+// FileTokenStorage stores each token in a file under Dir named after its key.
+// It uses the same files as FileTokenStore.
+type FileTokenStorage struct {
+	Dir string
+}
+
+// tokenDir implements tokenDirStore
+func (s FileTokenStorage) tokenDir() string {
+	return s.Dir
+}
+
+// Load implements TokenStorage
+func (s FileTokenStorage) Load(key string) (string, error) {
+	return (&FileTokenStore{Dir: s.Dir, path: filepath.Join(s.Dir, key)}).Get()
+}
+
+// Save implements TokenStorage
+func (s FileTokenStorage) Save(key, token string) error {
+	return (&FileTokenStore{Dir: s.Dir, path: filepath.Join(s.Dir, key)}).Set(token)
+}
+
+// FileTokenStore stores the token in a file under Dir named after a hash of
+// the client credentials
+type FileTokenStore struct {
+	Dir  string
+	path string
+}
+
+// NewFileTokenStore creates a file token store in dir for the given credentials
+func NewFileTokenStore(dir, userID, secret string) *FileTokenStore {
+	return &FileTokenStore{
+		Dir:  dir,
+		path: filepath.Join(dir, tokenKey(userID, secret)),
+	}
+}
+
+// tokenDir implements tokenDirStore
+func (s *FileTokenStore) tokenDir() string {
+	return s.Dir
+}
+
+// Get implements TokenStore
+func (s *FileTokenStore) Get() (string, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		if dirErr := checkTokenDir(s.Dir); dirErr != nil {
+			return "", dirErr
+		}
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	return string(data), nil
+}
+
+// Set implements TokenStore
+func (s *FileTokenStore) Set(token string) error {
+	if err := checkTokenDir(s.Dir); err != nil {
+		return err
+	}
+
+	// Create token storage directory if it doesn't exist
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create token storage directory: %w", err)
+	}
+	return os.WriteFile(s.path, []byte(token), 0644)
+}
+
+// MemoryTokenStore keeps the token in memory only
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token string
+}
+
+// Get implements TokenStore
+func (s *MemoryTokenStore) Get() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, nil
+}
+
+// Set implements TokenStore
+func (s *MemoryTokenStore) Set(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+	return nil
+}
@@ -0,0 +1,123 @@
+package smtp
+
+import (
+	"crypto/md5"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTokenKey(t *testing.T) {
+	want := fmt.Sprintf("%x", md5.Sum([]byte("user::secret")))
+	if key := tokenKey("user", "secret"); key != want {
+		t.Errorf("tokenKey = %q, want %q", key, want)
+	}
+	if tokenKey("user", "secret") == tokenKey("user", "other") {
+		t.Error("different secrets share a key")
+	}
+}
+
+func TestFileTokenStoreRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tokens")
+	store := NewFileTokenStore(dir, "user", "secret")
+
+	// A missing file is an empty token, not an error
+	if token, err := store.Get(); err != nil || token != "" {
+		t.Fatalf("Get() before Set = %q, %v; want empty", token, err)
+	}
+
+	if err := store.Set("abc"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if token, err := store.Get(); err != nil || token != "abc" {
+		t.Errorf("Get() = %q, %v; want abc", token, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, tokenKey("user", "secret"))); err != nil {
+		t.Errorf("token file not named after the credentials: %v", err)
+	}
+}
+
+func TestFileTokenStorageRoundTrip(t *testing.T) {
+	storage := FileTokenStorage{Dir: filepath.Join(t.TempDir(), "tokens")}
+
+	if token, err := storage.Load("missing"); err != nil || token != "" {
+		t.Fatalf("Load of a missing key = %q, %v; want empty", token, err)
+	}
+
+	if err := storage.Save("a", "token-a"); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := storage.Save("b", "token-b"); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Keys are isolated from each other
+	for key, want := range map[string]string{"a": "token-a", "b": "token-b"} {
+		if token, err := storage.Load(key); err != nil || token != want {
+			t.Errorf("Load(%q) = %q, %v; want %q", key, token, err, want)
+		}
+	}
+}
+
+func TestFileTokenStorageSharesFileTokenStoreFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewFileTokenStore(dir, "user", "secret").Set("shared"); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := FileTokenStorage{Dir: dir}.Load(tokenKey("user", "secret"))
+	if err != nil || token != "shared" {
+		t.Errorf("Load = %q, %v; want the FileTokenStore token", token, err)
+	}
+}
+
+// mapTokenStorage is a TokenStorage keeping tokens in a map
+type mapTokenStorage map[string]string
+
+func (m mapTokenStorage) Load(key string) (string, error) {
+	return m[key], nil
+}
+
+func (m mapTokenStorage) Save(key, token string) error {
+	m[key] = token
+	return nil
+}
+
+func TestWithTokenStorageAdapter(t *testing.T) {
+	storage := mapTokenStorage{}
+	first := NewClientWithOptions("user", "secret", WithTokenStorage(storage))
+	second := NewClientWithOptions("other", "secret", WithTokenStorage(storage))
+
+	if err := first.tokenStore.Set("first-token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.tokenStore.Set("second-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := mapTokenStorage{
+		tokenKey("user", "secret"):  "first-token",
+		tokenKey("other", "secret"): "second-token",
+	}
+	if !reflect.DeepEqual(storage, want) {
+		t.Errorf("storage = %v, want %v", storage, want)
+	}
+
+	if token, err := first.tokenStore.Get(); err != nil || token != "first-token" {
+		t.Errorf("Get() = %q, %v; want first-token", token, err)
+	}
+}
+
+func TestMemoryTokenStoreRoundTrip(t *testing.T) {
+	var store MemoryTokenStore
+	if token, _ := store.Get(); token != "" {
+		t.Errorf("Get() of an empty store = %q", token)
+	}
+	store.Set("abc")
+	if token, _ := store.Get(); token != "abc" {
+		t.Errorf("Get() = %q, want abc", token)
+	}
+}
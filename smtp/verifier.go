@@ -0,0 +1,179 @@
+package smtp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	verifierPollInterval = 5 * time.Second
+	verifierTimeout      = 10 * time.Minute
+)
+
+// Email validation statuses reported by the verification service
+const (
+	ValidationValid   = "valid"
+	ValidationInvalid = "invalid"
+	ValidationRisky   = "risky"
+	ValidationUnknown = "unknown"
+)
+
+// EmailValidationResult represents the verification outcome for an email address
+type EmailValidationResult struct {
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Valid reports whether the address was verified as deliverable
+func (r EmailValidationResult) Valid() bool {
+	return r.Status == ValidationValid
+}
+
+// ValidateEmail verifies a single email address via the verification service
+func (c *Client) ValidateEmail(email string) (*EmailValidationResult, error) {
+	return c.ValidateEmailContext(context.Background(), email)
+}
+
+// ValidateEmailContext is like ValidateEmail but uses ctx for cancellation and deadlines
+func (c *Client) ValidateEmailContext(ctx context.Context, email string) (*EmailValidationResult, error) {
+	if email == "" {
+		return nil, fmt.Errorf("empty email")
+	}
+
+	data := map[string]string{"email": email}
+	if _, err := c.sendRequest(ctx, "verifier-service/send-single-to-verify/", "POST", data, true); err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{"email": email}
+	deadline := time.Now().Add(verifierTimeout)
+	for {
+		resp, err := c.sendRequest(ctx, "verifier-service/get-single-result/", "GET", params, true)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Result bool                  `json:"result"`
+			Data   EmailValidationResult `json:"data"`
+		}
+		if err := json.Unmarshal(resp, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse validation result: %w", err)
+		}
+
+		if result.Result && result.Data.Status != "" {
+			return &result.Data, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for validation of %s", email)
+		}
+		if err := sleepContext(ctx, verifierPollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ValidateEmails submits a list of email addresses to the verification service
+// and waits for the job to finish, returning the results keyed by email
+func (c *Client) ValidateEmails(emails []string) (map[string]EmailValidationResult, error) {
+	return c.ValidateEmailsContext(context.Background(), emails)
+}
+
+// ValidateEmailsContext is like ValidateEmails but uses ctx for cancellation and deadlines
+func (c *Client) ValidateEmailsContext(ctx context.Context, emails []string) (map[string]EmailValidationResult, error) {
+	if len(emails) == 0 {
+		return nil, fmt.Errorf("empty email list")
+	}
+
+	data := map[string]interface{}{"emails": emails}
+	resp, err := c.sendRequest(ctx, "verifier-service/send-list-to-verify/", "POST", data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var job struct {
+		Result bool `json:"result"`
+		ID     int  `json:"id"`
+	}
+	if err := json.Unmarshal(resp, &job); err != nil {
+		return nil, fmt.Errorf("failed to parse validation job: %w", err)
+	}
+	if !job.Result || job.ID == 0 {
+		return nil, fmt.Errorf("verification service did not accept the list")
+	}
+
+	// Wait for the job to check every address
+	deadline := time.Now().Add(verifierTimeout)
+	for {
+		resp, err := c.sendRequest(ctx, "verifier-service/check/", "GET", map[string]interface{}{"id": job.ID}, true)
+		if err != nil {
+			return nil, err
+		}
+
+		var progress struct {
+			Total   int `json:"total"`
+			Checked int `json:"checked"`
+		}
+		if err := json.Unmarshal(resp, &progress); err != nil {
+			return nil, fmt.Errorf("failed to parse validation progress: %w", err)
+		}
+
+		if progress.Total > 0 && progress.Checked >= progress.Total {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for validation job %d", job.ID)
+		}
+		if err := sleepContext(ctx, verifierPollInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err = c.sendRequest(ctx, "verifier-service/get-list/", "GET", map[string]interface{}{"list_id": job.ID}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Data []EmailValidationResult `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse validation results: %w", err)
+	}
+
+	results := make(map[string]EmailValidationResult, len(list.Data))
+	for _, r := range list.Data {
+		results[r.Email] = r
+	}
+
+	return results, nil
+}
+
+// GetValidatorBalance retrieves the number of validations remaining on the
+// verification service
+func (c *Client) GetValidatorBalance() (int, error) {
+	return c.GetValidatorBalanceContext(context.Background())
+}
+
+// GetValidatorBalanceContext is like GetValidatorBalance but uses ctx for cancellation and deadlines
+func (c *Client) GetValidatorBalanceContext(ctx context.Context) (int, error) {
+	resp, err := c.sendRequest(ctx, "verifier-service/get-balance/", "GET", nil, true)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		Result  bool `json:"result"`
+		Balance int  `json:"balance"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse validator balance: %w", err)
+	}
+
+	return result.Balance, nil
+}
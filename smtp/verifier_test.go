@@ -0,0 +1,80 @@
+package smtp
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestValidateEmailsMixedBatch(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /verifier-service/send-list-to-verify/":
+			var data struct {
+				Emails []string `json:"emails"`
+			}
+			readJSON(t, r, &data)
+			if len(data.Emails) != 3 {
+				t.Errorf("submitted %v, want 3 emails", data.Emails)
+			}
+			writeJSON(t, w, map[string]interface{}{"result": true, "id": 11})
+		case "GET /verifier-service/check/":
+			if r.URL.Query().Get("id") != "11" {
+				t.Errorf("checked job %q, want 11", r.URL.Query().Get("id"))
+			}
+			writeJSON(t, w, map[string]int{"total": 3, "checked": 3})
+		case "GET /verifier-service/get-list/":
+			writeJSON(t, w, map[string]interface{}{"data": []EmailValidationResult{
+				{Email: "good@example.com", Status: ValidationValid},
+				{Email: "bad@example", Status: ValidationInvalid, Reason: "no mx record"},
+				{Email: "catchall@example.com", Status: ValidationRisky},
+			}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	results, err := c.ValidateEmails([]string{"good@example.com", "bad@example", "catchall@example.com"})
+	if err != nil {
+		t.Fatalf("ValidateEmails: %v", err)
+	}
+
+	want := map[string]bool{"good@example.com": true, "bad@example": false, "catchall@example.com": false}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for email, valid := range want {
+		if got := results[email].Valid(); got != valid {
+			t.Errorf("%s valid = %v, want %v", email, got, valid)
+		}
+	}
+	if results["bad@example"].Reason != "no mx record" {
+		t.Errorf("reason = %q", results["bad@example"].Reason)
+	}
+}
+
+func TestValidateEmailsRejectedJob(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, map[string]interface{}{"result": false})
+	}))
+
+	if _, err := c.ValidateEmails([]string{"a@example.com"}); err == nil {
+		t.Fatal("ValidateEmails succeeded for a rejected job")
+	}
+}
+
+func TestGetValidatorBalance(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/verifier-service/get-balance/" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"result":true,"balance":1250}`))
+	}))
+
+	balance, err := c.GetValidatorBalance()
+	if err != nil {
+		t.Fatalf("GetValidatorBalance: %v", err)
+	}
+	if balance != 1250 {
+		t.Errorf("balance = %d, want 1250", balance)
+	}
+}
@@ -0,0 +1,289 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// webhookTestEvent is the payload posted by TestWebhookEndpoint, shaped like
+// the events SendPulse delivers
+type webhookTestEvent struct {
+	Event     string `json:"event"`
+	Email     string `json:"email"`
+	Timestamp int64  `json:"timestamp"`
+	Test      bool   `json:"test"`
+}
+
+// parseWebhookURL checks that endpoint is an absolute http or https URL
+func parseWebhookURL(endpoint string) (*url.URL, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("empty webhook url")
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid webhook url scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook url: missing host")
+	}
+
+	return u, nil
+}
+
+// TestWebhookEndpoint posts a test event to endpoint and reports an error
+// unless it responds with a 2xx status. Use it before registering a webhook.
+func (c *Client) TestWebhookEndpoint(endpoint string) error {
+	return c.TestWebhookEndpointContext(context.Background(), endpoint)
+}
+
+// TestWebhookEndpointContext is like TestWebhookEndpoint but uses ctx for cancellation and deadlines
+func (c *Client) TestWebhookEndpointContext(ctx context.Context, endpoint string) error {
+	u, err := parseWebhookURL(endpoint)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal([]webhookTestEvent{{
+		Event:     "test",
+		Email:     "test@example.com",
+		Timestamp: time.Now().Unix(),
+		Test:      true,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal test event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", u.String(), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("request aborted: %w", ctxErr)
+		}
+		return fmt.Errorf("webhook endpoint unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// SMTPHook represents a callback registered for SMTP service events
+type SMTPHook struct {
+	ID     int    `json:"id"`
+	Action string `json:"action"`
+	URL    string `json:"url"`
+}
+
+// SMTPListHooks retrieves the callbacks registered for SMTP service events
+func (c *Client) SMTPListHooks() ([]SMTPHook, error) {
+	return c.SMTPListHooksContext(context.Background())
+}
+
+// SMTPListHooksContext is like SMTPListHooks but uses ctx for cancellation and deadlines
+func (c *Client) SMTPListHooksContext(ctx context.Context) ([]SMTPHook, error) {
+	resp, err := c.sendRequest(ctx, "smtp/hooks", "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var hooks []SMTPHook
+	if err := json.Unmarshal(resp, &hooks); err != nil {
+		return nil, fmt.Errorf("failed to parse SMTP hooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+// SMTPAddHook registers url to be called for the SMTP event action and
+// returns the new hook's ID
+func (c *Client) SMTPAddHook(action, url string) (int, error) {
+	return c.SMTPAddHookContext(context.Background(), action, url)
+}
+
+// SMTPAddHookContext is like SMTPAddHook but uses ctx for cancellation and deadlines
+func (c *Client) SMTPAddHookContext(ctx context.Context, action, url string) (int, error) {
+	if action == "" {
+		return 0, fmt.Errorf("empty hook action")
+	}
+	if _, err := parseWebhookURL(url); err != nil {
+		return 0, err
+	}
+
+	data := map[string]string{
+		"action": action,
+		"url":    url,
+	}
+
+	resp, err := c.sendRequest(ctx, "smtp/hooks", "POST", data, true)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse SMTP hook: %w", err)
+	}
+
+	return result.ID, nil
+}
+
+// SMTPDeleteHook removes a callback registered for SMTP service events
+func (c *Client) SMTPDeleteHook(id int) error {
+	return c.SMTPDeleteHookContext(context.Background(), id)
+}
+
+// SMTPDeleteHookContext is like SMTPDeleteHook but uses ctx for cancellation and deadlines
+func (c *Client) SMTPDeleteHookContext(ctx context.Context, id int) error {
+	if id == 0 {
+		return fmt.Errorf("empty hook id")
+	}
+
+	_, err := c.sendRequest(ctx, fmt.Sprintf("smtp/hooks/%d", id), "DELETE", nil, true)
+	return err
+}
+
+// Webhook events that can be subscribed to with CreateWebhooks
+const (
+	WebhookDelivered   = "delivered"
+	WebhookOpened      = "open"
+	WebhookClicked     = "redirect"
+	WebhookUnsubscribe = "unsubscribe"
+	WebhookSpam        = "spam"
+	WebhookHardBounce  = "hard_bounce"
+	WebhookSoftBounce  = "soft_bounce"
+)
+
+// webhookActions are the events accepted by CreateWebhooks
+var webhookActions = map[string]bool{
+	WebhookDelivered:   true,
+	WebhookOpened:      true,
+	WebhookClicked:     true,
+	WebhookUnsubscribe: true,
+	WebhookSpam:        true,
+	WebhookHardBounce:  true,
+	WebhookSoftBounce:  true,
+}
+
+// Webhook represents a URL subscribed to an email event
+type Webhook struct {
+	ID     int    `json:"id"`
+	Action string `json:"action"`
+	URL    string `json:"url"`
+}
+
+// GetWebhooks retrieves the account's email event webhooks
+func (c *Client) GetWebhooks() ([]Webhook, error) {
+	return c.GetWebhooksContext(context.Background())
+}
+
+// GetWebhooksContext is like GetWebhooks but uses ctx for cancellation and deadlines
+func (c *Client) GetWebhooksContext(ctx context.Context) ([]Webhook, error) {
+	resp, err := c.sendRequest(ctx, "v2/email-service/webhook", "GET", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data []Webhook `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks: %w", err)
+	}
+
+	return result.Data, nil
+}
+
+// CreateWebhooks subscribes url to each of the given Webhook* events and
+// returns the created webhooks, one per action
+func (c *Client) CreateWebhooks(actions []string, url string) ([]Webhook, error) {
+	return c.CreateWebhooksContext(context.Background(), actions, url)
+}
+
+// CreateWebhooksContext is like CreateWebhooks but uses ctx for cancellation and deadlines
+func (c *Client) CreateWebhooksContext(ctx context.Context, actions []string, url string) ([]Webhook, error) {
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("empty webhook action list")
+	}
+	for _, action := range actions {
+		if !webhookActions[action] {
+			return nil, fmt.Errorf("unknown webhook action %q", action)
+		}
+	}
+	if _, err := parseWebhookURL(url); err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"url":     url,
+		"actions": actions,
+	}
+
+	resp, err := c.sendRequest(ctx, "v2/email-service/webhook", "POST", data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data []Webhook `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks: %w", err)
+	}
+
+	return result.Data, nil
+}
+
+// UpdateWebhook points an existing webhook at a new url
+func (c *Client) UpdateWebhook(id int, url string) error {
+	return c.UpdateWebhookContext(context.Background(), id, url)
+}
+
+// UpdateWebhookContext is like UpdateWebhook but uses ctx for cancellation and deadlines
+func (c *Client) UpdateWebhookContext(ctx context.Context, id int, url string) error {
+	if id == 0 {
+		return fmt.Errorf("empty webhook id")
+	}
+	if _, err := parseWebhookURL(url); err != nil {
+		return err
+	}
+
+	data := map[string]string{"url": url}
+	_, err := c.sendRequest(ctx, fmt.Sprintf("v2/email-service/webhook/%d", id), "PUT", data, true)
+	return err
+}
+
+// DeleteWebhook removes a webhook
+func (c *Client) DeleteWebhook(id int) error {
+	return c.DeleteWebhookContext(context.Background(), id)
+}
+
+// DeleteWebhookContext is like DeleteWebhook but uses ctx for cancellation and deadlines
+func (c *Client) DeleteWebhookContext(ctx context.Context, id int) error {
+	if id == 0 {
+		return fmt.Errorf("empty webhook id")
+	}
+
+	_, err := c.sendRequest(ctx, fmt.Sprintf("v2/email-service/webhook/%d", id), "DELETE", nil, true)
+	return err
+}
@@ -0,0 +1,120 @@
+package smtp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTestWebhookEndpoint(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("API token sent to the webhook endpoint")
+		}
+
+		var events []webhookTestEvent
+		readJSON(t, r, &events)
+		if len(events) != 1 || !events[0].Test {
+			t.Errorf("unexpected test events %+v", events)
+		}
+	}))
+	defer ok.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	c := NewClientWithOptions("user", "secret")
+	c.Token = "token"
+
+	if err := c.TestWebhookEndpoint(ok.URL + "/hook"); err != nil {
+		t.Errorf("TestWebhookEndpoint(200): %v", err)
+	}
+	if err := c.TestWebhookEndpoint(failing.URL); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("TestWebhookEndpoint(500) = %v, want a status 500 error", err)
+	}
+}
+
+func TestTestWebhookEndpointRejectsInvalidURL(t *testing.T) {
+	c := NewClientWithOptions("user", "secret")
+
+	tests := []struct {
+		url     string
+		wantErr string
+	}{
+		{"", "empty webhook url"},
+		{"ftp://example.com/hook", `scheme "ftp"`},
+		{"example.com/hook", `scheme ""`},
+		{"https:///hook", "missing host"},
+	}
+	for _, tt := range tests {
+		if err := c.TestWebhookEndpoint(tt.url); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("TestWebhookEndpoint(%q) = %v, want error containing %q", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSMTPHooks(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /smtp/hooks":
+			writeJSON(t, w, []SMTPHook{{ID: 1, Action: "delivered", URL: "https://example.com/delivered"}})
+		case "POST /smtp/hooks":
+			var data map[string]string
+			readJSON(t, r, &data)
+			if data["action"] != "open" || data["url"] != "https://example.com/open" {
+				t.Errorf("hook data = %v", data)
+			}
+			writeJSON(t, w, map[string]int{"id": 2})
+		case "DELETE /smtp/hooks/2":
+			writeJSON(t, w, map[string]bool{"result": true})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	hooks, err := c.SMTPListHooks()
+	if err != nil {
+		t.Fatalf("SMTPListHooks: %v", err)
+	}
+	if want := []SMTPHook{{ID: 1, Action: "delivered", URL: "https://example.com/delivered"}}; !reflect.DeepEqual(hooks, want) {
+		t.Errorf("hooks = %+v, want %+v", hooks, want)
+	}
+
+	id, err := c.SMTPAddHook("open", "https://example.com/open")
+	if err != nil {
+		t.Fatalf("SMTPAddHook: %v", err)
+	}
+	if id != 2 {
+		t.Errorf("hook id = %d, want 2", id)
+	}
+
+	if err := c.SMTPDeleteHook(id); err != nil {
+		t.Fatalf("SMTPDeleteHook: %v", err)
+	}
+
+	// Invalid arguments are rejected without a request
+	if _, err := c.SMTPAddHook("", "https://example.com/open"); err == nil {
+		t.Error("SMTPAddHook with empty action succeeded, want error")
+	}
+	for _, url := range []string{"", "ftp://example.com/open", "/open", "https://"} {
+		if _, err := c.SMTPAddHook("open", url); err == nil || !strings.Contains(err.Error(), "webhook url") {
+			t.Errorf("SMTPAddHook(%q) = %v, want a webhook url error", url, err)
+		}
+	}
+	if err := c.SMTPDeleteHook(0); err == nil {
+		t.Error("SMTPDeleteHook(0) succeeded, want error")
+	}
+
+	if want := []string{"GET /smtp/hooks", "POST /smtp/hooks", "DELETE /smtp/hooks/2"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}